@@ -0,0 +1,126 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/glazed/pkg/settings"
+	"github.com/go-go-golems/zine-layout/pkg/zinelayout"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type ImposeCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.BareCommand = (*ImposeCommand)(nil)
+
+func NewImposeCommand() (*ImposeCommand, error) {
+	glazedLayer, err := settings.NewGlazedParameterLayers()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create Glazed parameter layer")
+	}
+
+	return &ImposeCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"impose",
+			cmds.WithShort("Generate a saddle-stitch layout spec for a booklet"),
+			cmds.WithFlags(
+				parameters.NewParameterDefinition("pages", parameters.ParameterTypeInteger, parameters.WithRequired(true), parameters.WithHelp("Number of content pages (padded with blank pages to full sheets)")),
+				parameters.NewParameterDefinition("pages-per-side", parameters.ParameterTypeChoice, parameters.WithChoices("2", "4", "8"), parameters.WithDefault("2"), parameters.WithHelp("Pages printed on each side of a sheet; more than 2 uses cut and stack")),
+				parameters.NewParameterDefinition("signature-pages", parameters.ParameterTypeInteger, parameters.WithDefault(0), parameters.WithHelp("Split the booklet into signatures of this many pages (0 for a single signature)")),
+				parameters.NewParameterDefinition("nested-signatures", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Insert every signature into the previous one and saddle stitch them together instead of stacking them")),
+				parameters.NewParameterDefinition("binding", parameters.ParameterTypeChoice, parameters.WithChoices("left", "right"), parameters.WithDefault("left"), parameters.WithHelp("Binding edge of the booklet")),
+				parameters.NewParameterDefinition("creep", parameters.ParameterTypeString, parameters.WithDefault(""), parameters.WithHelp("Paper thickness for creep compensation (e.g. 0.1mm)")),
+				parameters.NewParameterDefinition("ppi", parameters.ParameterTypeInteger, parameters.WithDefault(300), parameters.WithHelp("PPI written to the spec")),
+				parameters.NewParameterDefinition("paper", parameters.ParameterTypeString, parameters.WithDefault(""), parameters.WithHelp("Optional paper size for the sheets (A4, Letter, ...)")),
+				parameters.NewParameterDefinition("out", parameters.ParameterTypeString, parameters.WithDefault(""), parameters.WithHelp("Write the spec to this file instead of stdout")),
+			),
+			cmds.WithLayersList(glazedLayer),
+		),
+	}, nil
+}
+
+type ImposeSettings struct {
+	Pages        int    `glazed.parameter:"pages"`
+	PagesPerSide string `glazed.parameter:"pages-per-side"`
+	Signature    int    `glazed.parameter:"signature-pages"`
+	Nested       bool   `glazed.parameter:"nested-signatures"`
+	Binding      string `glazed.parameter:"binding"`
+	Creep        string `glazed.parameter:"creep"`
+	PPI          int    `glazed.parameter:"ppi"`
+	Paper        string `glazed.parameter:"paper"`
+	Output       string `glazed.parameter:"out"`
+}
+
+func (c *ImposeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayers) error {
+	s := &ImposeSettings{}
+	if err := parsedLayers.InitializeStruct(layers.DefaultSlug, s); err != nil {
+		return err
+	}
+
+	var pagesPerSide int
+	if _, err := fmt.Sscanf(s.PagesPerSide, "%d", &pagesPerSide); err != nil {
+		return fmt.Errorf("invalid pages per side: %s", s.PagesPerSide)
+	}
+	binding, err := zinelayout.ParseBinding(s.Binding)
+	if err != nil {
+		return err
+	}
+
+	opts := zinelayout.ImposeOptions{
+		Pages:            s.Pages,
+		PagesPerSide:     pagesPerSide,
+		Binding:          binding,
+		PPI:              float64(s.PPI),
+		SignaturePages:   s.Signature,
+		NestedSignatures: s.Nested,
+		Creep:            s.Creep,
+	}
+	zl, err := zinelayout.ImposeSaddleStitch(opts)
+	if err != nil {
+		return err
+	}
+	if s.Paper != "" {
+		orientation := zinelayout.OrientationPortrait
+		if zl.PageSetup.GridSize.Rows == 1 {
+			orientation = zinelayout.OrientationLandscape
+		}
+		zl.PageSetup.Paper = &zinelayout.Paper{Size: s.Paper, Orientation: orientation}
+		if err := zl.PageSetup.Paper.ComputePixelValues(zl.Global.PPI); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Saddle stitch: %d pages (%d imposed), %d pages per side, %s binding\n",
+		opts.Pages, opts.ImposedPages(), pagesPerSide, binding)
+	if zl.Signatures != nil {
+		fmt.Fprintf(&buf, "# %d signatures of %d pages\n", zl.Signatures.Count, zl.Signatures.Pages)
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(zl); err != nil {
+		return fmt.Errorf("marshaling spec: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	b := buf.Bytes()
+
+	if s.Output == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	if err := os.WriteFile(s.Output, b, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote spec: %s\n", s.Output)
+	return nil
+}
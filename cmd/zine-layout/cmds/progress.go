@@ -0,0 +1,103 @@
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/zine-layout/pkg/app"
+	"golang.org/x/term"
+)
+
+const progressBarWidth = 30
+
+var progressLabels = map[app.ProgressPhase]string{
+	app.PhaseLoadSpec:    "Loading spec",
+	app.PhaseDecodeInput: "Decoding inputs",
+	app.PhaseComposePage: "Composing pages",
+	app.PhaseEncodePage:  "Encoding pages",
+	app.PhaseEncodePDF:   "Encoding PDF",
+}
+
+// progressBar draws the progress events of a render on a single terminal
+// line, redrawn for every event.
+type progressBar struct {
+	w     io.Writer
+	start time.Time
+
+	mu    sync.Mutex
+	done  map[app.ProgressPhase]int
+	drawn bool
+}
+
+// newProgressBar returns a progress bar on stderr, or nil when stderr is
+// not a terminal.
+func newProgressBar() *progressBar {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return &progressBar{w: os.Stderr, start: time.Now(), done: map[app.ProgressPhase]int{}}
+}
+
+// Func returns the progress func that draws on the bar. A nil bar returns
+// a nil func.
+func (b *progressBar) Func() app.ProgressFunc {
+	if b == nil {
+		return nil
+	}
+	return b.update
+}
+
+func (b *progressBar) update(ev app.ProgressEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// pages finish out of order, so the bar counts finished steps instead
+	// of showing the index of the last one
+	b.done[ev.Phase]++
+	done := b.done[ev.Phase]
+	filled := progressBarWidth
+	if ev.Total > 0 && done < ev.Total {
+		filled = progressBarWidth * done / ev.Total
+	}
+	label, ok := progressLabels[ev.Phase]
+	if !ok {
+		label = string(ev.Phase)
+	}
+	_, _ = fmt.Fprintf(b.w, "\r%-15s [%s%s] %d/%d %s %s (%s total)\x1b[K",
+		label,
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		done, ev.Total,
+		filepath.Base(ev.Name),
+		ev.Duration.Round(time.Millisecond),
+		time.Since(b.start).Round(100*time.Millisecond))
+	b.drawn = true
+}
+
+// Reset starts counting again for the next layout of a spec.
+func (b *progressBar) Reset() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = map[app.ProgressPhase]int{}
+}
+
+// Finish ends the progress line, so that the next output starts on a new
+// line.
+func (b *progressBar) Finish() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.drawn {
+		_, _ = fmt.Fprintln(b.w)
+		b.drawn = false
+	}
+}
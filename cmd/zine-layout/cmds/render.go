@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"image"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-go-golems/glazed/pkg/cmds"
 	"github.com/go-go-golems/glazed/pkg/cmds/layers"
@@ -41,11 +46,18 @@ func NewRenderCommand() (*RenderCommand, error) {
 			cmds.WithFlags(
 				parameters.NewParameterDefinition("spec", parameters.ParameterTypeString, parameters.WithDefault("layout.yaml"), parameters.WithHelp("Path to the YAML layout specification")),
 				parameters.NewParameterDefinition("output-dir", parameters.ParameterTypeString, parameters.WithDefault("."), parameters.WithHelp("Directory to save output images")),
+				parameters.NewParameterDefinition("format", parameters.ParameterTypeChoiceList, parameters.WithChoices("png", "pdf", "svg"), parameters.WithDefault([]string{"png"}), parameters.WithHelp("Output formats: png (one file per page), pdf (single multi-page file) and/or svg (one file per page)")),
+				parameters.NewParameterDefinition("svg-link-inputs", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Link the input files from the SVG files instead of embedding them")),
+				parameters.NewParameterDefinition("concurrency", parameters.ParameterTypeInteger, parameters.WithDefault(0), parameters.WithHelp("Number of output pages to render in parallel (0 = number of CPUs)")),
+				parameters.NewParameterDefinition("progress", parameters.ParameterTypeBool, parameters.WithDefault(true), parameters.WithHelp("Show a progress bar on stderr when it is a terminal")),
 				parameters.NewParameterDefinition("verbose", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Verbose output")),
 				parameters.NewParameterDefinition("global-border", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Enable global border")),
 				parameters.NewParameterDefinition("page-border", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Enable page border")),
 				parameters.NewParameterDefinition("layout-border", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Enable layout border")),
 				parameters.NewParameterDefinition("inner-border", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Enable inner layout border")),
+				parameters.NewParameterDefinition("var", parameters.ParameterTypeStringList, parameters.WithDefault([]string{}), parameters.WithHelp("Template variable for the spec as key=value (repeatable)")),
+				parameters.NewParameterDefinition("printer-profile", parameters.ParameterTypeString, parameters.WithDefault(""), parameters.WithHelp("YAML printer profile with the duplex offset for back sides")),
+				parameters.NewParameterDefinition("no-guides", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Leave out fold and cut guides for final renders")),
 				parameters.NewParameterDefinition("border-color", parameters.ParameterTypeString, parameters.WithDefault(""), parameters.WithHelp("Border color R,G,B,A (0-255) or color name or #hex")),
                 parameters.NewParameterDefinition("border-type", parameters.ParameterTypeChoice, parameters.WithChoices("plain", "dotted", "dashed", "corner"), parameters.WithHelp("Border type")),
 				parameters.NewParameterDefinition("test", parameters.ParameterTypeBool, parameters.WithDefault(false), parameters.WithHelp("Generate test images instead of reading inputs")),
@@ -62,11 +74,18 @@ type RenderSettings struct {
 	InputFiles     []string `glazed.parameter:"input-files"`
 	Spec           string   `glazed.parameter:"spec"`
 	OutputDir      string   `glazed.parameter:"output-dir"`
+	Format         []string `glazed.parameter:"format"`
+	SVGLinkInputs  bool     `glazed.parameter:"svg-link-inputs"`
+	Concurrency    int      `glazed.parameter:"concurrency"`
+	Progress       bool     `glazed.parameter:"progress"`
 	Verbose        bool     `glazed.parameter:"verbose"`
 	GlobalBorder   bool     `glazed.parameter:"global-border"`
 	PageBorder     bool     `glazed.parameter:"page-border"`
 	LayoutBorder   bool     `glazed.parameter:"layout-border"`
 	InnerBorder    bool     `glazed.parameter:"inner-border"`
+	NoGuides       bool     `glazed.parameter:"no-guides"`
+	PrinterProfile string   `glazed.parameter:"printer-profile"`
+	Vars           []string `glazed.parameter:"var"`
 	BorderColor    string   `glazed.parameter:"border-color"`
 	BorderType     string   `glazed.parameter:"border-type"`
 	Test           bool     `glazed.parameter:"test"`
@@ -85,19 +104,76 @@ func (c *RenderCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLaye
 		return fmt.Errorf("no input files provided; pass --test or specify input files")
 	}
 
+	// Ctrl-C stops the render and removes the files written so far
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	formats := make([]app.OutputFormat, 0, len(s.Format))
+	for _, f := range s.Format {
+		of, err := app.ParseOutputFormat(f)
+		if err != nil {
+			return err
+		}
+		formats = append(formats, of)
+	}
+	pdfName := strings.TrimSuffix(filepath.Base(s.Spec), filepath.Ext(s.Spec)) + ".pdf"
+
+	var printer *zinelayout.PrinterProfile
+	if s.PrinterProfile != "" {
+		var err error
+		printer, err = app.LoadPrinterProfile(s.PrinterProfile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var bar *progressBar
+	if s.Progress {
+		bar = newProgressBar()
+	}
+	defer bar.Finish()
+	progress := bar.Func()
+
 	// Load layouts
-	env := map[string]interface{}{}
+	env, err := app.ParseVars(s.Vars)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
 	layouts, err := app.LoadLayoutsFromSpec(s.Spec, env)
 	if err != nil {
 		return err
 	}
+	progress.Report(app.PhaseLoadSpec, 1, 1, s.Spec, start)
+
+	// A cancelled render removes its own files. The files of the layouts
+	// rendered before it, and the output directory if it was created here,
+	// are removed by cancelled.
+	_, statErr := os.Stat(s.OutputDir)
+	createdDir := os.IsNotExist(statErr)
+	var rendered []string
+	cancelled := func(err error) error {
+		if ctx.Err() == nil {
+			return err
+		}
+		for _, fp := range rendered {
+			_ = os.Remove(fp)
+		}
+		if createdDir {
+			_ = os.RemoveAll(s.OutputDir)
+		}
+		return errors.New("render cancelled, the files written so far were removed")
+	}
 
-	for _, zl := range layouts {
+	for i, zl := range layouts {
+		bar.Reset()
 		if err := app.ApplyOverrides(&zl, app.Overrides{
 			GlobalBorder: s.GlobalBorder,
 			PageBorder:   s.PageBorder,
 			LayoutBorder: s.LayoutBorder,
 			InnerBorder:  s.InnerBorder,
+			NoGuides:     s.NoGuides,
+			Printer:      printer,
 			BorderColor:  s.BorderColor,
 			BorderType:   s.BorderType,
 			PPI:          s.PPI,
@@ -113,46 +189,47 @@ func (c *RenderCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLaye
 		// Prepare inputs
 		var inputImages []image.Image
 		if s.Test {
-			maxIndex := 0
-			for _, op := range zl.OutputPages {
-				for _, l := range op.Layout {
-					if l.InputIndex > maxIndex {
-						maxIndex = l.InputIndex
-					}
-				}
-			}
 			w, h, err := app.ParseTestDimensions(s.TestDimensions, ppi)
 			if err != nil {
 				return err
 			}
-			inputImages, err = app.GenerateTestImages(maxIndex, w, h, s.TestBW)
+			inputImages, err = app.GenerateTestImages(zl.InputCount(), w, h, s.TestBW)
 			if err != nil {
 				return err
 			}
 		} else {
-			inputImages, err = app.ReadInputImages(s.InputFiles)
+			inputImages, err = app.ReadInputImages(ctx, s.InputFiles, progress)
 			if err != nil {
-				return err
+				return cancelled(err)
 			}
 		}
 
-		if !zinelayout.AllImagesSameSize(inputImages) {
-			return fmt.Errorf("input images are not the same size")
-		}
-
 		if s.Verbose {
+			bar.Finish()
+			if !zinelayout.AllImagesSameSize(inputImages) {
+				fmt.Println("Input images have different sizes, cells are sized by page_setup.cell_size")
+			}
 			fmt.Println("Parsed ZineLayout:")
 			app.DebugPrintZineLayout(zl)
 			fmt.Println()
 		}
 
-		written, err := app.RenderOutputs(&zl, inputImages, s.OutputDir)
+		opts := app.RenderOptions{Formats: formats, PDFName: pdfName, Concurrency: s.Concurrency, Progress: progress}
+		if s.SVGLinkInputs && !s.Test {
+			opts.SVGInputFiles = s.InputFiles
+		}
+		if len(layouts) > 1 {
+			opts.PDFName = fmt.Sprintf("%s-%d.pdf", strings.TrimSuffix(pdfName, ".pdf"), i+1)
+		}
+		written, err := app.RenderOutputs(ctx, &zl, inputImages, s.OutputDir, opts)
+		bar.Finish()
 		if err != nil {
-			return err
+			return cancelled(err)
 		}
+		rendered = append(rendered, written...)
 		for _, fp := range written {
 			if fi, err := os.Stat(fp); err == nil {
-				fmt.Printf("Saved output image: %s (Size: %d bytes)\n", fp, fi.Size())
+				fmt.Printf("Saved output file: %s (Size: %d bytes)\n", fp, fi.Size())
 			}
 		}
 	}
@@ -6,21 +6,24 @@ import (
     "fmt"
     "archive/zip"
     "image"
-    _ "image/png"
+    "image/png"
     "io"
     "log"
     "math/rand"
+    "net"
     "net/http"
     "mime/multipart"
     "os"
     "os/signal"
     "path/filepath"
     "strings"
+    "sync"
     "syscall"
     "time"
     "io/fs"
     yaml "gopkg.in/yaml.v3"
     apppkg "github.com/go-go-golems/zine-layout/pkg/app"
+    "github.com/go-go-golems/zine-layout/pkg/zinelayout"
 
     "github.com/go-go-golems/glazed/pkg/cmds"
     "github.com/go-go-golems/glazed/pkg/cmds/layers"
@@ -80,6 +83,7 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
         log.Printf("warning: failed to seed presets: %v", err)
     }
 
+    renders := newRenderTracker()
     mux := http.NewServeMux()
     mux.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
         writeJSON(w, http.StatusOK, map[string]any{"ok": true})
@@ -352,6 +356,19 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
                 http.ServeFile(w, r, fn)
                 return
             }
+            // GET /api/projects/{id}/renders/{rid}/download.pdf
+            if len(parts) == 4 && parts[3] == "download.pdf" && r.Method == http.MethodGet {
+                rid := parts[2]
+                fn := filepath.Join(projectRenderDir(projectsRoot, id, rid), renderPDFName)
+                if _, err := os.Stat(fn); err != nil {
+                    http.Error(w, "no pdf for this render", http.StatusNotFound)
+                    return
+                }
+                w.Header().Set("Content-Type", "application/pdf")
+                w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.pdf", id, rid))
+                http.ServeFile(w, r, fn)
+                return
+            }
             // GET /api/projects/{id}/renders/{rid}/download.zip
             if len(parts) == 4 && parts[3] == "download.zip" && r.Method == http.MethodGet {
                 rid := parts[2]
@@ -369,10 +386,20 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
                 Test bool `json:"test"`
                 TestBW bool `json:"test_bw"`
                 TestDimensions string `json:"test_dimensions"`
+                Formats []string `json:"formats"`
             }
             _ = json.NewDecoder(r.Body).Decode(&req)
-            out, err := doProjectRender(projectsRoot, id, req.Test, req.TestBW, req.TestDimensions)
+            // the request context ends when the client goes away or the
+            // server shuts down
+            progress, done := renders.start(id)
+            out, err := doProjectRender(r.Context(), projectsRoot, id, req.Test, req.TestBW, req.TestDimensions, req.Formats, progress)
+            done()
             if err != nil {
+                if r.Context().Err() != nil {
+                    log.Printf("render of project %s cancelled: %v", id, err)
+                    http.Error(w, "render cancelled", http.StatusServiceUnavailable)
+                    return
+                }
                 http.Error(w, err.Error(), http.StatusBadRequest)
                 return
             }
@@ -380,6 +407,12 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
             return
         }
 
+        // GET /api/projects/{id}/render/progress
+        if len(parts) == 3 && parts[1] == "render" && parts[2] == "progress" && r.Method == http.MethodGet {
+            writeJSON(w, http.StatusOK, map[string]any{"progress": renders.get(id)})
+            return
+        }
+
         // /api/projects/{id}/images[...]
         if len(parts) >= 2 && parts[1] == "images" {
             switch {
@@ -407,8 +440,27 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
                     http.Error(w, "no images[] files provided", http.StatusBadRequest)
                     return
                 }
+                // Optionally split spreads into single pages
+                var split *zinelayout.SplitOptions
+                if v := r.FormValue("split"); v != "" && v != "1" {
+                    opts, err := projectSplitOptions(projectsRoot, id, v, r.FormValue("split_direction"), r.FormValue("split_gutter"))
+                    if err != nil {
+                        http.Error(w, err.Error(), http.StatusBadRequest)
+                        return
+                    }
+                    split = opts
+                }
                 saved := make([]ImageItem, 0, len(files))
                 for _, fh := range files {
+                    if split != nil {
+                        items, err := saveSplitPngImage(projectsRoot, id, fh, *split)
+                        if err != nil {
+                            http.Error(w, err.Error(), http.StatusBadRequest)
+                            return
+                        }
+                        saved = append(saved, items...)
+                        continue
+                    }
                     it, err := savePngImage(projectsRoot, id, fh)
                     if err != nil {
                         http.Error(w, err.Error(), http.StatusBadRequest)
@@ -437,6 +489,52 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
                 fn := filepath.Join(projectImagesDir(projectsRoot, id), filepath.Base(imageID))
                 http.ServeFile(w, r, fn)
                 return
+            // Get, set or clear the crop of an image
+            case len(parts) == 4 && parts[3] == "crop":
+                imageID := filepath.Base(parts[2])
+                var crop *zinelayout.Crop
+                switch r.Method {
+                case http.MethodGet:
+                    p, err := readProject(projectsRoot, id)
+                    if err != nil {
+                        status := http.StatusInternalServerError
+                        if os.IsNotExist(err) { status = http.StatusNotFound }
+                        http.Error(w, err.Error(), status)
+                        return
+                    }
+                    writeJSON(w, http.StatusOK, map[string]any{"crop": p.Crops[imageID]})
+                    return
+                case http.MethodPut:
+                    crop = &zinelayout.Crop{}
+                    if err := json.NewDecoder(r.Body).Decode(crop); err != nil {
+                        http.Error(w, "invalid crop", http.StatusBadRequest)
+                        return
+                    }
+                    // check the crop now rather than when the project is rendered
+                    iw, ih, err := readImageSize(filepath.Join(projectImagesDir(projectsRoot, id), imageID))
+                    if err != nil {
+                        status := http.StatusInternalServerError
+                        if os.IsNotExist(err) { status = http.StatusNotFound }
+                        http.Error(w, err.Error(), status)
+                        return
+                    }
+                    if err := crop.Validate(image.Pt(iw, ih), projectPPI(projectsRoot, id)); err != nil {
+                        http.Error(w, "invalid crop: "+err.Error(), http.StatusBadRequest)
+                        return
+                    }
+                case http.MethodDelete:
+                default:
+                    http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+                    return
+                }
+                if err := setProjectImageCrop(projectsRoot, id, imageID, crop); err != nil {
+                    status := http.StatusInternalServerError
+                    if os.IsNotExist(err) { status = http.StatusNotFound }
+                    http.Error(w, err.Error(), status)
+                    return
+                }
+                writeJSON(w, http.StatusOK, map[string]any{"crop": crop})
+                return
             case len(parts) == 3 && r.Method == http.MethodDelete:
                 imageID := parts[2]
                 if err := deleteProjectImage(projectsRoot, id, imageID); err != nil {
@@ -465,7 +563,11 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
     // SPA file server with index.html fallback for client-side routes
     mux.HandleFunc("/", spaHandler(abs))
 
-    srv := &http.Server{ Addr: s.Addr, Handler: mux }
+    // Requests derive their context from baseCtx, so cancelling it on
+    // shutdown aborts running renders
+    baseCtx, cancelRequests := context.WithCancel(ctx)
+    defer cancelRequests()
+    srv := &http.Server{ Addr: s.Addr, Handler: mux, BaseContext: func(net.Listener) context.Context { return baseCtx } }
 
     // Start server
     errCh := make(chan error, 1)
@@ -483,6 +585,7 @@ func (c *ServeCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayer
     select {
     case sig := <-sigCh:
         log.Printf("received signal %s, shutting down...", sig)
+        cancelRequests()
         ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
         defer cancel()
         _ = srv.Shutdown(ctx)
@@ -502,6 +605,9 @@ type Project struct {
     Images    []string  `json:"images"`
     Order     []string  `json:"order"`
     PresetID  string    `json:"presetId,omitempty"`
+    // Crops are the crops of the images, by image ID. They are the default
+    // crops of the matching inputs when the project is rendered.
+    Crops map[string]*zinelayout.Crop `json:"crops,omitempty"`
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
@@ -603,6 +709,7 @@ type ImageItem struct {
     Name   string `json:"name"`
     Width  int    `json:"width"`
     Height int    `json:"height"`
+    Crop   *zinelayout.Crop `json:"crop,omitempty"`
 }
 
 func projectDir(projectsRoot, id string) string {
@@ -630,7 +737,7 @@ func listProjectImages(projectsRoot, id string) ([]ImageItem, []string, error) {
         fp := filepath.Join(dir, name)
         w, h, err := readImageSize(fp)
         if err != nil { continue }
-        images = append(images, ImageItem{ID: name, Name: name, Width: w, Height: h})
+        images = append(images, ImageItem{ID: name, Name: name, Width: w, Height: h, Crop: p.Crops[name]})
     }
     return images, p.Order, nil
 }
@@ -684,6 +791,69 @@ func savePngImage(projectsRoot, id string, fh *multipart.FileHeader) (*ImageItem
     return item, nil
 }
 
+// projectSplitOptions parses the split fields of an upload form. The
+// gutter is measured at the PPI of the project spec.
+func projectSplitOptions(projectsRoot, id, pages, direction, gutter string) (*zinelayout.SplitOptions, error) {
+    var n int
+    if _, err := fmt.Sscanf(pages, "%d", &n); err != nil || n < 1 {
+        return nil, fmt.Errorf("invalid split: %s", pages)
+    }
+    if direction == "" { direction = string(zinelayout.SplitVertical) }
+    d, err := zinelayout.ParseSplitDirection(direction)
+    if err != nil { return nil, err }
+    return &zinelayout.SplitOptions{Pages: n, Direction: d, Gutter: gutter, PPI: projectPPI(projectsRoot, id)}, nil
+}
+
+// projectPPI returns the PPI of the project spec, or 300 when the spec
+// does not set one or cannot be loaded.
+func projectPPI(projectsRoot, id string) float64 {
+    specPath := filepath.Join(projectDir(projectsRoot, id), "spec.yaml")
+    if layouts, err := apppkg.LoadLayoutsFromSpec(specPath, map[string]interface{}{}); err == nil && len(layouts) > 0 && layouts[0].Global.PPI > 0 {
+        return layouts[0].Global.PPI
+    }
+    return 300
+}
+
+// saveSplitPngImage splits an uploaded spread into pages and adds them to
+// the project as separate images, in page order.
+func saveSplitPngImage(projectsRoot, id string, fh *multipart.FileHeader, opts zinelayout.SplitOptions) ([]ImageItem, error) {
+    if fh.Size == 0 { return nil, fmt.Errorf("empty file") }
+    if !strings.HasSuffix(strings.ToLower(fh.Filename), ".png") {
+        return nil, fmt.Errorf("only .png allowed: %s", fh.Filename)
+    }
+    src, err := fh.Open()
+    if err != nil { return nil, err }
+    defer src.Close()
+    img, _, err := image.Decode(src)
+    if err != nil { return nil, fmt.Errorf("decoding %s: %w", fh.Filename, err) }
+    pages, err := zinelayout.SplitImage(img, opts)
+    if err != nil { return nil, fmt.Errorf("splitting %s: %w", fh.Filename, err) }
+
+    dir := projectImagesDir(projectsRoot, id)
+    if err := os.MkdirAll(dir, 0o755); err != nil { return nil, err }
+    p, err := readProject(projectsRoot, id)
+    if err != nil { return nil, err }
+    items := make([]ImageItem, 0, len(pages))
+    for _, page := range pages {
+        outName := fmt.Sprintf("%04d.png", nextImageNumber(dir))
+        dstPath := filepath.Join(dir, outName)
+        dst, err := os.Create(dstPath)
+        if err != nil { return nil, err }
+        if err := png.Encode(dst, page); err != nil {
+            _ = dst.Close(); _ = os.Remove(dstPath)
+            return nil, err
+        }
+        if err := dst.Close(); err != nil { return nil, err }
+        p.Images = append(p.Images, outName)
+        p.Order = append(p.Order, outName)
+        b := page.Bounds()
+        items = append(items, ImageItem{ID: outName, Name: outName, Width: b.Dx(), Height: b.Dy()})
+    }
+    p.UpdatedAt = time.Now().UTC()
+    if err := writeProject(projectsRoot, p); err != nil { return nil, err }
+    return items, nil
+}
+
 func nextImageNumber(dir string) int {
     max := 0
     entries, _ := os.ReadDir(dir)
@@ -726,6 +896,25 @@ func deleteProjectImage(projectsRoot, id, imageID string) error {
     }
     p.Images = filter(p.Images)
     p.Order = filter(p.Order)
+    delete(p.Crops, imageID)
+    p.UpdatedAt = time.Now().UTC()
+    return writeProject(projectsRoot, p)
+}
+
+// setProjectImageCrop stores the crop of a project image. An empty crop
+// removes it.
+func setProjectImageCrop(projectsRoot, id, imageID string, crop *zinelayout.Crop) error {
+    p, err := readProject(projectsRoot, id)
+    if err != nil { return err }
+    known := false
+    for _, im := range p.Images { if im == imageID { known = true } }
+    if !known { return os.ErrNotExist }
+    if crop.IsZero() {
+        delete(p.Crops, imageID)
+    } else {
+        if p.Crops == nil { p.Crops = map[string]*zinelayout.Crop{} }
+        p.Crops[imageID] = crop
+    }
     p.UpdatedAt = time.Now().UTC()
     return writeProject(projectsRoot, p)
 }
@@ -828,22 +1017,22 @@ type validationDetails struct {
     Columns  int `json:"columns"`
     Pages    int `json:"pages"`
     Multiple int `json:"multiple"`
+    MixedSizes bool `json:"mixedSizes"`
 }
 
 func validateProject(projectsRoot, id string) ([]string, *validationDetails, bool) {
     issues := []string{}
-    // Check image sizes all equal
     imgs, _, err := listProjectImages(projectsRoot, id)
     if err != nil {
         issues = append(issues, fmt.Sprintf("read images: %v", err))
         return issues, nil, false
     }
+    // Mixed sizes are fine, cells are sized by page_setup.cell_size
     var w0, h0 int
+    mixed := false
     for i, im := range imgs {
         if i == 0 { w0, h0 = im.Width, im.Height }
-        if im.Width != w0 || im.Height != h0 {
-            issues = append(issues, fmt.Sprintf("image %s has size %dx%d, expected %dx%d", im.Name, im.Width, im.Height, w0, h0))
-        }
+        if im.Width != w0 || im.Height != h0 { mixed = true }
     }
     rows, cols, pages := readSpecGridAndPages(projectDir(projectsRoot, id))
     mult := 0
@@ -856,7 +1045,7 @@ func validateProject(projectsRoot, id string) ([]string, *validationDetails, boo
         issues = append(issues, "spec.yaml missing or incomplete grid/pages; skipping multiple check")
     }
     ok := len(issues) == 0
-    det := &validationDetails{Count: len(imgs), Width: w0, Height: h0, Rows: rows, Columns: cols, Pages: pages, Multiple: mult}
+    det := &validationDetails{Count: len(imgs), Width: w0, Height: h0, Rows: rows, Columns: cols, Pages: pages, Multiple: mult, MixedSizes: mixed}
     return issues, det, ok
 }
 
@@ -883,20 +1072,100 @@ func readSpecGridAndPages(dir string) (rows, cols, pages int) {
 type RenderResult struct {
     RenderID string   `json:"renderId"`
     Files    []string `json:"files"`
+    PDF      string   `json:"pdf,omitempty"`
+    SVGs     []string `json:"svgs,omitempty"`
 }
 
 type RenderListItem struct {
     ID    string   `json:"id"`
     Files []string `json:"files"`
+    PDF   string   `json:"pdf,omitempty"`
+    SVGs  []string `json:"svgs,omitempty"`
+}
+
+// RenderProgress is the state of the last render of a project, polled by
+// the web UI while the render request runs.
+type RenderProgress struct {
+    Running bool   `json:"running"`
+    Phase   string `json:"phase,omitempty"`
+    // Name is the input file or output page of the last finished step.
+    Name string `json:"name,omitempty"`
+    // Done counts the finished steps of the phase, out of Total.
+    Done      int   `json:"done"`
+    Total     int   `json:"total"`
+    ElapsedMs int64 `json:"elapsedMs"`
+}
+
+// renderTracker keeps the progress of the renders of all projects.
+type renderTracker struct {
+    mu       sync.Mutex
+    progress map[string]*RenderProgress
+}
+
+func newRenderTracker() *renderTracker {
+    return &renderTracker{progress: map[string]*RenderProgress{}}
+}
+
+// start resets the progress of a project and returns the progress func of
+// its render, and done to call when the render returns.
+func (t *renderTracker) start(id string) (apppkg.ProgressFunc, func()) {
+    begin := time.Now()
+    p := &RenderProgress{Running: true}
+    counts := map[apppkg.ProgressPhase]int{}
+    t.mu.Lock()
+    t.progress[id] = p
+    t.mu.Unlock()
+
+    progress := func(ev apppkg.ProgressEvent) {
+        t.mu.Lock()
+        defer t.mu.Unlock()
+        counts[ev.Phase]++
+        p.Phase = string(ev.Phase)
+        p.Name = filepath.Base(ev.Name)
+        p.Done = counts[ev.Phase]
+        p.Total = ev.Total
+        p.ElapsedMs = time.Since(begin).Milliseconds()
+    }
+    done := func() {
+        t.mu.Lock()
+        defer t.mu.Unlock()
+        p.Running = false
+        p.ElapsedMs = time.Since(begin).Milliseconds()
+    }
+    return progress, done
 }
 
-func doProjectRender(projectsRoot, id string, test, testBW bool, testDimensions string) (*RenderResult, error) {
+// get returns a copy of the progress of a project, which is zero when the
+// project was not rendered since the server started.
+func (t *renderTracker) get(id string) RenderProgress {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    if p, ok := t.progress[id]; ok { return *p }
+    return RenderProgress{}
+}
+
+// renderPDFName is the file name of the PDF written into a render directory.
+const renderPDFName = "render.pdf"
+
+// doProjectRender renders a project into a new render directory. A
+// cancelled ctx stops the render and removes the directory. The steps of
+// the render are reported to progress, which may be nil.
+func doProjectRender(ctx context.Context, projectsRoot, id string, test, testBW bool, testDimensions string, formats []string, progress apppkg.ProgressFunc) (*RenderResult, error) {
+    // PNGs are always written so the UI can show previews
+    opts := apppkg.RenderOptions{Formats: []apppkg.OutputFormat{apppkg.OutputFormatPNG}, PDFName: renderPDFName, Progress: progress}
+    for _, f := range formats {
+        of, err := apppkg.ParseOutputFormat(f)
+        if err != nil { return nil, err }
+        if of != apppkg.OutputFormatPNG { opts.Formats = append(opts.Formats, of) }
+    }
     projDir := projectDir(projectsRoot, id)
     specPath := filepath.Join(projDir, "spec.yaml")
+    start := time.Now()
     layouts, err := apppkg.LoadLayoutsFromSpec(specPath, map[string]interface{}{})
     if err != nil {
         return nil, fmt.Errorf("load spec: %w", err)
     }
+    progress.Report(apppkg.PhaseLoadSpec, 1, 1, specPath, start)
     if len(layouts) == 0 {
         return nil, fmt.Errorf("spec.yaml did not produce any layouts")
     }
@@ -910,6 +1179,7 @@ func doProjectRender(projectsRoot, id string, test, testBW bool, testDimensions
         w, h, err := apppkg.ParseTestDimensions(testDimensions, ppi)
         if err != nil { return nil, err }
         n := zl.PageSetup.GridSize.Rows * zl.PageSetup.GridSize.Columns * len(zl.OutputPages)
+        if zl.Signatures != nil { n = zl.InputCount() }
         if n <= 0 { n = 1 }
         inputs, err = apppkg.GenerateTestImages(n, w, h, testBW)
         if err != nil { return nil, err }
@@ -920,21 +1190,31 @@ func doProjectRender(projectsRoot, id string, test, testBW bool, testDimensions
         order := p.Order
         if len(order) == 0 { order = p.Images }
         files := make([]string, 0, len(order))
-        for _, name := range order {
+        for i, name := range order {
             files = append(files, filepath.Join(projectImagesDir(projectsRoot, id), name))
+            // crops stored with the images override the spec defaults
+            if c := p.Crops[name]; !c.IsZero() {
+                if zl.PageSetup.Crops == nil { zl.PageSetup.Crops = map[int]*zinelayout.Crop{} }
+                zl.PageSetup.Crops[i+1] = c
+            }
         }
-        inputs, err = apppkg.ReadInputImages(files)
+        inputs, err = apppkg.ReadInputImages(ctx, files, progress)
         if err != nil { return nil, err }
     }
     // output dir
     rid := time.Now().UTC().Format("20060102-150405")
     outDir := projectRenderDir(projectsRoot, id, rid)
-    files, err := apppkg.RenderOutputs(&zl, inputs, outDir)
+    files, err := apppkg.RenderOutputs(ctx, &zl, inputs, outDir, opts)
     if err != nil { return nil, err }
     // return file basenames
-    names := make([]string, len(files))
-    for i, f := range files { names[i] = filepath.Base(f) }
-    return &RenderResult{ RenderID: rid, Files: names }, nil
+    res := &RenderResult{ RenderID: rid, Files: []string{} }
+    for _, f := range files {
+        name := filepath.Base(f)
+        if name == renderPDFName { res.PDF = name; continue }
+        if strings.HasSuffix(strings.ToLower(name), ".svg") { res.SVGs = append(res.SVGs, name); continue }
+        res.Files = append(res.Files, name)
+    }
+    return res, nil
 }
 
 func projectRendersRoot(projectsRoot, id string) string {
@@ -953,9 +1233,15 @@ func listProjectRenders(projectsRoot, id string) ([]RenderListItem, error) {
         if !e.IsDir() { continue }
         rid := e.Name()
         files, _ := os.ReadDir(filepath.Join(root, rid))
-        var names []string
-        for _, f := range files { if !f.IsDir() && strings.HasSuffix(strings.ToLower(f.Name()), ".png") { names = append(names, f.Name()) } }
-        out = append(out, RenderListItem{ ID: rid, Files: names })
+        var names, svgs []string
+        pdf := ""
+        for _, f := range files {
+            if f.IsDir() { continue }
+            if strings.HasSuffix(strings.ToLower(f.Name()), ".png") { names = append(names, f.Name()) }
+            if strings.HasSuffix(strings.ToLower(f.Name()), ".svg") { svgs = append(svgs, f.Name()) }
+            if f.Name() == renderPDFName { pdf = f.Name() }
+        }
+        out = append(out, RenderListItem{ ID: rid, Files: names, PDF: pdf, SVGs: svgs })
     }
     return out, nil
 }
@@ -0,0 +1,53 @@
+package cmds
+
+import (
+	"sync"
+	"testing"
+
+	apppkg "github.com/go-go-golems/zine-layout/pkg/app"
+)
+
+// TestRenderTracker reports the pages of a render from several goroutines
+// in reverse order, like concurrent render workers can, and checks that the
+// tracker counts every one of them.
+func TestRenderTracker(t *testing.T) {
+	const pages = 40
+	tracker := newRenderTracker()
+	progress, done := tracker.start("p1")
+
+	var wg sync.WaitGroup
+	for i := pages; i >= 1; i-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			progress(apppkg.ProgressEvent{Phase: apppkg.PhaseComposePage, Index: i, Total: pages, Name: "out/page.png"})
+		}()
+	}
+	wg.Wait()
+
+	p := tracker.get("p1")
+	if !p.Running || p.Phase != string(apppkg.PhaseComposePage) || p.Done != pages || p.Total != pages || p.Name != "page.png" {
+		t.Errorf("progress = %+v, expected %d of %d composed pages while running", p, pages, pages)
+	}
+
+	// every phase is counted on its own
+	progress(apppkg.ProgressEvent{Phase: apppkg.PhaseEncodePage, Index: 7, Total: pages})
+	if p := tracker.get("p1"); p.Phase != string(apppkg.PhaseEncodePage) || p.Done != 1 {
+		t.Errorf("progress = %+v, expected 1 encoded page", p)
+	}
+
+	done()
+	if p := tracker.get("p1"); p.Running {
+		t.Errorf("progress = %+v, expected the render to be finished", p)
+	}
+	if p := tracker.get("p2"); p != (RenderProgress{}) {
+		t.Errorf("progress of a project without render = %+v, expected zero", p)
+	}
+
+	// a new render starts counting again
+	progress, _ = tracker.start("p1")
+	progress(apppkg.ProgressEvent{Phase: apppkg.PhaseComposePage, Index: 3, Total: pages})
+	if p := tracker.get("p1"); !p.Running || p.Done != 1 {
+		t.Errorf("progress = %+v, expected 1 composed page of the new render", p)
+	}
+}
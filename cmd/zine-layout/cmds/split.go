@@ -0,0 +1,86 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/glazed/pkg/settings"
+	"github.com/go-go-golems/zine-layout/pkg/app"
+	"github.com/go-go-golems/zine-layout/pkg/zinelayout"
+	"github.com/pkg/errors"
+)
+
+type SplitCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.BareCommand = (*SplitCommand)(nil)
+
+func NewSplitCommand() (*SplitCommand, error) {
+	glazedLayer, err := settings.NewGlazedParameterLayers()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create Glazed parameter layer")
+	}
+
+	return &SplitCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"split",
+			cmds.WithShort("Split spread images into numbered single pages"),
+			cmds.WithArguments(
+				parameters.NewParameterDefinition(
+					"input-files",
+					parameters.ParameterTypeStringList,
+					parameters.WithRequired(true),
+					parameters.WithHelp("Spread images, in page order"),
+				),
+			),
+			cmds.WithFlags(
+				parameters.NewParameterDefinition("pages", parameters.ParameterTypeInteger, parameters.WithDefault(2), parameters.WithHelp("Number of pages in each image")),
+				parameters.NewParameterDefinition("direction", parameters.ParameterTypeChoice, parameters.WithChoices("vertical", "horizontal"), parameters.WithDefault("vertical"), parameters.WithHelp("vertical cuts pages side by side, horizontal pages on top of each other")),
+				parameters.NewParameterDefinition("gutter", parameters.ParameterTypeString, parameters.WithDefault(""), parameters.WithHelp("Width of the strip between pages to throw away (e.g. 0.25in)")),
+				parameters.NewParameterDefinition("ppi", parameters.ParameterTypeInteger, parameters.WithDefault(300), parameters.WithHelp("PPI of the images, for the gutter")),
+				parameters.NewParameterDefinition("prefix", parameters.ParameterTypeString, parameters.WithDefault("page"), parameters.WithHelp("File name prefix of the pages (prefix-0001.png, ...)")),
+				parameters.NewParameterDefinition("output-dir", parameters.ParameterTypeString, parameters.WithDefault("."), parameters.WithHelp("Directory to save the pages")),
+			),
+			cmds.WithLayersList(glazedLayer),
+		),
+	}, nil
+}
+
+type SplitSettings struct {
+	InputFiles []string `glazed.parameter:"input-files"`
+	Pages      int      `glazed.parameter:"pages"`
+	Direction  string   `glazed.parameter:"direction"`
+	Gutter     string   `glazed.parameter:"gutter"`
+	PPI        int      `glazed.parameter:"ppi"`
+	Prefix     string   `glazed.parameter:"prefix"`
+	OutputDir  string   `glazed.parameter:"output-dir"`
+}
+
+func (c *SplitCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayers) error {
+	s := &SplitSettings{}
+	if err := parsedLayers.InitializeStruct(layers.DefaultSlug, s); err != nil {
+		return err
+	}
+
+	direction, err := zinelayout.ParseSplitDirection(s.Direction)
+	if err != nil {
+		return err
+	}
+	written, err := app.SplitFiles(ctx, s.InputFiles, s.OutputDir, s.Prefix, zinelayout.SplitOptions{
+		Pages:     s.Pages,
+		Direction: direction,
+		Gutter:    s.Gutter,
+		PPI:       float64(s.PPI),
+	})
+	if err != nil {
+		return err
+	}
+	for _, fp := range written {
+		fmt.Printf("Saved page: %s\n", fp)
+	}
+	return nil
+}
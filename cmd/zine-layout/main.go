@@ -60,6 +60,30 @@ func main() {
 	cobra.CheckErr(err)
 	rootCmd.AddCommand(cobraRenderCmd)
 
+	imposeCmd, err := cmds.NewImposeCommand()
+	cobra.CheckErr(err)
+	cobraImposeCmd, err := cli.BuildCobraCommandFromCommand(
+		imposeCmd,
+		cli.WithParserConfig(cli.CobraParserConfig{
+			ShortHelpLayers: []string{layers.DefaultSlug},
+			MiddlewaresFunc: cli.CobraCommandDefaultMiddlewares,
+		}),
+	)
+	cobra.CheckErr(err)
+	rootCmd.AddCommand(cobraImposeCmd)
+
+	splitCmd, err := cmds.NewSplitCommand()
+	cobra.CheckErr(err)
+	cobraSplitCmd, err := cli.BuildCobraCommandFromCommand(
+		splitCmd,
+		cli.WithParserConfig(cli.CobraParserConfig{
+			ShortHelpLayers: []string{layers.DefaultSlug},
+			MiddlewaresFunc: cli.CobraCommandDefaultMiddlewares,
+		}),
+	)
+	cobra.CheckErr(err)
+	rootCmd.AddCommand(cobraSplitCmd)
+
 	serveCmd, err := cmds.NewServeCommand()
 	cobra.CheckErr(err)
 	cobraServeCmd, err := cli.BuildCobraCommandFromCommand(
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader fails reads once ctx is cancelled, which aborts a decoder
+// reading through it.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// contextWriter fails writes once ctx is cancelled, which aborts an encoder
+// writing through it.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
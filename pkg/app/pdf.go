@@ -0,0 +1,208 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// pdfWriter streams raster pages into a single multi-page PDF document.
+// Each page is sized to its physical dimensions, derived from the pixel size
+// of the image and the layout PPI, and holds exactly one image XObject.
+type pdfWriter struct {
+	w       *bufio.Writer
+	offset  int64
+	offsets []int64 // object byte offsets, indexed by object number - 1
+	pages   []int   // object numbers of page objects
+	ppi     float64
+}
+
+const (
+	pdfCatalogObject = 1
+	pdfPagesObject   = 2
+)
+
+func newPDFWriter(w io.Writer, ppi float64) (*pdfWriter, error) {
+	if ppi <= 0 {
+		return nil, fmt.Errorf("ppi must be positive to write a pdf")
+	}
+	pw := &pdfWriter{
+		w:   bufio.NewWriter(w),
+		ppi: ppi,
+		// Catalog and page tree are reserved up front, the page tree is
+		// only written on Close once all kids are known.
+		offsets: make([]int64, 2),
+	}
+	if err := pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n"); err != nil {
+		return nil, err
+	}
+	if err := pw.writeObject(pdfCatalogObject, []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObject))); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *pdfWriter) printf(format string, args ...interface{}) error {
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *pdfWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *pdfWriter) nextObject() int {
+	pw.offsets = append(pw.offsets, 0)
+	return len(pw.offsets)
+}
+
+func (pw *pdfWriter) writeObject(id int, body []byte) error {
+	pw.offsets[id-1] = pw.offset
+	if err := pw.printf("%d 0 obj\n", id); err != nil {
+		return err
+	}
+	if err := pw.write(body); err != nil {
+		return err
+	}
+	return pw.printf("\nendobj\n")
+}
+
+func (pw *pdfWriter) writeStream(id int, dict string, data []byte) error {
+	pw.offsets[id-1] = pw.offset
+	if err := pw.printf("%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data)); err != nil {
+		return err
+	}
+	if err := pw.write(data); err != nil {
+		return err
+	}
+	return pw.printf("\nendstream\nendobj\n")
+}
+
+// AddPage appends img as a new page.
+func (pw *pdfWriter) AddPage(img image.Image) error {
+	b := img.Bounds()
+	widthPt := float64(b.Dx()) * 72 / pw.ppi
+	heightPt := float64(b.Dy()) * 72 / pw.ppi
+
+	data, err := encodeRGBFlate(img)
+	if err != nil {
+		return err
+	}
+
+	imageID := pw.nextObject()
+	if err := pw.writeStream(imageID,
+		fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", b.Dx(), b.Dy()),
+		data,
+	); err != nil {
+		return err
+	}
+
+	contentID := pw.nextObject()
+	content := []byte(fmt.Sprintf("q\n%.4f 0 0 %.4f 0 0 cm\n/Im0 Do\nQ\n", widthPt, heightPt))
+	if err := pw.writeStream(contentID, "", content); err != nil {
+		return err
+	}
+
+	pageID := pw.nextObject()
+	page := fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.4f %.4f] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObject, widthPt, heightPt, imageID, contentID,
+	)
+	if err := pw.writeObject(pageID, []byte(page)); err != nil {
+		return err
+	}
+	pw.pages = append(pw.pages, pageID)
+	return nil
+}
+
+// Close writes the page tree, cross-reference table and trailer.
+func (pw *pdfWriter) Close() error {
+	var kids bytes.Buffer
+	for i, id := range pw.pages {
+		if i > 0 {
+			kids.WriteByte(' ')
+		}
+		fmt.Fprintf(&kids, "%d 0 R", id)
+	}
+	if err := pw.writeObject(pdfPagesObject, []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(pw.pages)))); err != nil {
+		return err
+	}
+
+	xrefOffset := pw.offset
+	if err := pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1); err != nil {
+		return err
+	}
+	for _, off := range pw.offsets {
+		if err := pw.printf("%010d 00000 n \n", off); err != nil {
+			return err
+		}
+	}
+	if err := pw.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, pdfCatalogObject, xrefOffset); err != nil {
+		return err
+	}
+	return pw.w.Flush()
+}
+
+// encodeRGBFlate converts img to packed 8-bit RGB rows and zlib-compresses them.
+func encodeRGBFlate(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		if rgba, ok := img.(*image.RGBA); ok {
+			src := rgba.Pix[rgba.PixOffset(b.Min.X, y):]
+			for x := 0; x < b.Dx(); x++ {
+				copy(row[x*3:x*3+3], src[x*4:x*4+3])
+			}
+		} else {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				i := (x - b.Min.X) * 3
+				row[i], row[i+1], row[i+2] = uint8(r>>8), uint8(g>>8), uint8(bl>>8)
+			}
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WritePDF writes all images as pages of a single PDF to w, using ppi to
+// compute the physical page size of each page.
+func WritePDF(w io.Writer, images []image.Image, ppi float64) error {
+	pw, err := newPDFWriter(w, ppi)
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		if err := pw.AddPage(img); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+func writePDF(ctx context.Context, images []image.Image, ppi float64, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if err := WritePDF(contextWriter{ctx: ctx, w: f}, images, ppi); err != nil {
+		return err
+	}
+	return f.Close()
+}
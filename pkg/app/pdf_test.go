@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePDF(t *testing.T) {
+	red := image.NewRGBA(image.Rect(0, 0, 100, 200))
+	for i := 0; i < len(red.Pix); i += 4 {
+		red.Pix[i], red.Pix[i+3] = 255, 255
+	}
+	gray := image.NewGray(image.Rect(5, 5, 55, 55))
+	for i := range gray.Pix {
+		gray.Pix[i] = 128
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, []image.Image{red, gray}, 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header: %q", data[:16])
+	}
+	if !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Errorf("missing end of file marker")
+	}
+
+	// the cross-reference table starts at startxref and points at every
+	// object
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatalf("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d points at %q", xref, data[xref:xref+10])
+	}
+	lines := strings.Split(string(data[xref:]), "\n")
+	var first, size int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &size); err != nil || first != 0 {
+		t.Fatalf("invalid xref subsection %q", lines[1])
+	}
+	// catalog, page tree, and an image, content and page object per page
+	if size != 1+2+2*3 {
+		t.Errorf("xref has %d entries, expected 9", size)
+	}
+	if !strings.Contains(string(data), "/Size "+strconv.Itoa(size)+" ") {
+		t.Errorf("trailer size does not match the %d xref entries", size)
+	}
+	for id := 1; id < size; id++ {
+		entry := lines[2+id]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Errorf("xref entry %d = %q", id, entry)
+			continue
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if expected := strconv.Itoa(id) + " 0 obj\n"; !bytes.HasPrefix(data[offset:], []byte(expected)) {
+			t.Errorf("xref offset %d of object %d points at %q", offset, id, data[offset:offset+10])
+		}
+	}
+
+	if !regexp.MustCompile(`/Type /Pages /Kids \[\d+ 0 R \d+ 0 R\] /Count 2 `).Match(data) {
+		t.Errorf("page tree does not list two pages")
+	}
+	// pages are sized in points from their pixel size at 100 ppi
+	boxes := regexp.MustCompile(`/MediaBox \[0 0 ([\d.]+) ([\d.]+)\]`).FindAllSubmatch(data, -1)
+	expected := [][2]string{{"72.0000", "144.0000"}, {"36.0000", "36.0000"}}
+	if len(boxes) != len(expected) {
+		t.Fatalf("got %d media boxes, expected %d", len(boxes), len(expected))
+	}
+	for i, box := range boxes {
+		if string(box[1]) != expected[i][0] || string(box[2]) != expected[i][1] {
+			t.Errorf("page %d media box is %s x %s, expected %s x %s", i+1, box[1], box[2], expected[i][0], expected[i][1])
+		}
+	}
+
+	// image streams hold packed RGB rows
+	streams := regexp.MustCompile(`(?s)/Width (\d+) /Height (\d+) .*? /Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(data, -1)
+	pixels := []color.RGBA{{255, 0, 0, 255}, {128, 128, 128, 255}}
+	if len(streams) != 2 {
+		t.Fatalf("got %d image streams, expected 2", len(streams))
+	}
+	for i, s := range streams {
+		width, _ := strconv.Atoi(string(data[s[2]:s[3]]))
+		height, _ := strconv.Atoi(string(data[s[4]:s[5]]))
+		length, _ := strconv.Atoi(string(data[s[6]:s[7]]))
+		zr, err := zlib.NewReader(bytes.NewReader(data[s[1] : s[1]+length]))
+		if err != nil {
+			t.Fatalf("image %d: %v", i+1, err)
+		}
+		rgb, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("image %d: %v", i+1, err)
+		}
+		if len(rgb) != width*height*3 {
+			t.Errorf("image %d has %d bytes, expected %d", i+1, len(rgb), width*height*3)
+			continue
+		}
+		if p := pixels[i]; rgb[0] != p.R || rgb[1] != p.G || rgb[2] != p.B {
+			t.Errorf("image %d starts with %v, expected %v", i+1, rgb[:3], p)
+		}
+	}
+
+	if err := WritePDF(io.Discard, []image.Image{red}, 0); err == nil {
+		t.Errorf("expected error for a zero ppi")
+	}
+}
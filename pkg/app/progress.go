@@ -0,0 +1,46 @@
+package app
+
+import "time"
+
+// ProgressPhase is a step of a render reported to a ProgressFunc.
+type ProgressPhase string
+
+const (
+	// PhaseLoadSpec is loading and parsing the layout spec.
+	PhaseLoadSpec ProgressPhase = "load_spec"
+	// PhaseDecodeInput is decoding one input image.
+	PhaseDecodeInput ProgressPhase = "decode_input"
+	// PhaseComposePage is laying out and drawing one output page.
+	PhaseComposePage ProgressPhase = "compose_page"
+	// PhaseEncodePage is writing the PNG and SVG files of one output page.
+	PhaseEncodePage ProgressPhase = "encode_page"
+	// PhaseEncodePDF is writing the PDF of all output pages.
+	PhaseEncodePDF ProgressPhase = "encode_pdf"
+)
+
+// ProgressEvent reports a finished step of a render.
+type ProgressEvent struct {
+	Phase ProgressPhase
+	// Index is the number of the input or output page of the step,
+	// starting at 1, out of Total. Pages are rendered concurrently, so
+	// their events can arrive out of order.
+	Index int
+	Total int
+	// Name is the input file, the output page ID or the PDF file name.
+	Name string
+	// Duration is how long the step took.
+	Duration time.Duration
+}
+
+// ProgressFunc receives the progress events of a render. Render workers
+// call it concurrently, so it must be safe for concurrent use.
+type ProgressFunc func(ProgressEvent)
+
+// Report sends an event for a step that started at start. A nil
+// ProgressFunc ignores it.
+func (f ProgressFunc) Report(phase ProgressPhase, index, total int, name string, start time.Time) {
+	if f == nil {
+		return
+	}
+	f(ProgressEvent{Phase: phase, Index: index, Total: total, Name: name, Duration: time.Since(start)})
+}
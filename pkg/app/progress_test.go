@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestRenderOutputsProgress(t *testing.T) {
+	zl, inputs := testBooklet(t)
+	pages := len(zl.OutputPages)
+
+	var mu sync.Mutex
+	var events []ProgressEvent
+	opts := RenderOptions{
+		Formats:     []OutputFormat{OutputFormatPNG, OutputFormatSVG, OutputFormatPDF},
+		Concurrency: 3,
+		Progress: func(ev ProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, ev)
+		},
+	}
+	if _, err := RenderOutputs(context.Background(), zl, inputs, t.TempDir(), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	counts := map[ProgressPhase]int{}
+	composed := map[int]bool{}
+	seen := map[ProgressPhase]map[int]bool{}
+	for _, ev := range events {
+		counts[ev.Phase]++
+		if seen[ev.Phase] == nil {
+			seen[ev.Phase] = map[int]bool{}
+		}
+		if seen[ev.Phase][ev.Index] {
+			t.Errorf("%s %d reported twice", ev.Phase, ev.Index)
+		}
+		seen[ev.Phase][ev.Index] = true
+		if ev.Name == "" || ev.Duration < 0 {
+			t.Errorf("%s %d has name %q and duration %v", ev.Phase, ev.Index, ev.Name, ev.Duration)
+		}
+
+		switch ev.Phase {
+		case PhaseComposePage, PhaseEncodePage:
+			if ev.Index < 1 || ev.Index > pages || ev.Total != pages {
+				t.Errorf("%s %d/%d, expected an index out of %d pages", ev.Phase, ev.Index, ev.Total, pages)
+			}
+			if ev.Phase == PhaseComposePage {
+				composed[ev.Index] = true
+			} else if !composed[ev.Index] {
+				t.Errorf("page %d was encoded before it was composed", ev.Index)
+			}
+		case PhaseEncodePDF:
+			if ev.Index != 1 || ev.Total != 1 {
+				t.Errorf("%s %d/%d, expected 1/1", ev.Phase, ev.Index, ev.Total)
+			}
+		case PhaseLoadSpec, PhaseDecodeInput:
+			t.Errorf("unexpected %s event from RenderOutputs", ev.Phase)
+		}
+	}
+	expected := map[ProgressPhase]int{PhaseComposePage: pages, PhaseEncodePage: pages, PhaseEncodePDF: 1}
+	for phase, n := range expected {
+		if counts[phase] != n {
+			t.Errorf("got %d %s events, expected %d", counts[phase], phase, n)
+		}
+	}
+}
+
+func TestReadInputImagesProgress(t *testing.T) {
+	images, err := GenerateTestImages(3, 10, 20, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	var files []string
+	for i, img := range images {
+		fp := filepath.Join(dir, "page-"+strconv.Itoa(i+1)+".png")
+		f, err := os.Create(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(f, img); err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+		files = append(files, fp)
+	}
+
+	var events []ProgressEvent
+	if _, err := ReadInputImages(context.Background(), files, func(ev ProgressEvent) {
+		events = append(events, ev)
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != len(files) {
+		t.Fatalf("got %d events, expected %d", len(events), len(files))
+	}
+	for i, ev := range events {
+		if ev.Phase != PhaseDecodeInput || ev.Index != i+1 || ev.Total != len(files) || ev.Name != files[i] {
+			t.Errorf("event %d = %s %d/%d %s, expected %s %d/%d %s",
+				i, ev.Phase, ev.Index, ev.Total, ev.Name, PhaseDecodeInput, i+1, len(files), files[i])
+		}
+	}
+
+	// a nil progress func is allowed
+	if _, err := ReadInputImages(context.Background(), files, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
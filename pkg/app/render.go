@@ -1,20 +1,28 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/png"
 	"io"
+	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Masterminds/sprig"
 	"github.com/go-go-golems/go-emrichen/pkg/emrichen"
 	"github.com/go-go-golems/zine-layout/pkg/zinelayout"
 	"github.com/go-go-golems/zine-layout/pkg/zinelayout/parser"
+	"golang.org/x/sync/errgroup"
 	"gopkg.in/yaml.v3"
 )
 
@@ -24,9 +32,12 @@ type Overrides struct {
 	PageBorder   bool
 	LayoutBorder bool
 	InnerBorder  bool
+	NoGuides     bool
 	BorderColor  string
 	BorderType   string
 	PPI          int
+	// Printer replaces the printer profile of the layout when set.
+	Printer *zinelayout.PrinterProfile
 }
 
 // LoadLayoutsFromSpec loads one or more ZineLayout documents from a YAML file,
@@ -81,6 +92,34 @@ func LoadLayoutsFromSpec(specPath string, env map[string]interface{}) ([]zinelay
 	return layouts, nil
 }
 
+// LoadPrinterProfile reads a printer profile from a YAML file.
+func LoadPrinterProfile(path string) (*zinelayout.PrinterProfile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading printer profile: %w", err)
+	}
+	var p zinelayout.PrinterProfile
+	if err := yaml.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("parsing printer profile: %w", err)
+	}
+	return &p, nil
+}
+
+// ParseVars parses key=value pairs into template variables for
+// LoadLayoutsFromSpec.
+func ParseVars(vars []string) (map[string]interface{}, error) {
+	env := map[string]interface{}{}
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid variable %q; expected key=value", v)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
 // ApplyOverrides applies override settings onto a parsed layout.
 func ApplyOverrides(zl *zinelayout.ZineLayout, ov Overrides) error {
     if zl.Global == nil {
@@ -122,6 +161,14 @@ func ApplyOverrides(zl *zinelayout.ZineLayout, ov Overrides) error {
 			}
 		}
 	}
+	if ov.Printer != nil {
+		zl.Global.Printer = ov.Printer
+	}
+	if ov.NoGuides {
+		for i := range zl.OutputPages {
+			zl.OutputPages[i].Guides = nil
+		}
+	}
 	if ov.BorderColor != "" {
 		c, err := ParseBorderColor(ov.BorderColor)
 		if err != nil {
@@ -176,55 +223,321 @@ func ParseTestDimensions(dimensions string, ppi float64) (int, int, error) {
 	return int(w), int(h), nil
 }
 
-// ReadInputImages decodes PNG images from file paths.
-func ReadInputImages(files []string) ([]image.Image, error) {
+// ReadInputImages decodes PNG images from file paths. The images are
+// converted for the raster path with zinelayout.PrepareInputs. Decoding
+// stops with the error of ctx once ctx is cancelled. Each decoded file is
+// reported to progress, which may be nil.
+func ReadInputImages(ctx context.Context, files []string, progress ProgressFunc) ([]image.Image, error) {
 	var images_ []image.Image
-	for _, file := range files {
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		start := time.Now()
 		f, err := os.Open(file)
 		if err != nil {
 			return nil, err
 		}
-		img, _, err := image.Decode(f)
+		img, _, err := image.Decode(contextReader{ctx: ctx, r: f})
 		_ = f.Close()
 		if err != nil {
 			return nil, err
 		}
 		images_ = append(images_, img)
+		progress.Report(PhaseDecodeInput, i+1, len(files), file, start)
+	}
+	return zinelayout.PrepareInputs(images_), nil
+}
+
+// OutputFormat selects the file format written by RenderOutputs.
+type OutputFormat string
+
+const (
+	// OutputFormatPNG writes one PNG file per output page.
+	OutputFormatPNG OutputFormat = "png"
+	// OutputFormatPDF writes all output pages into a single multi-page PDF.
+	OutputFormatPDF OutputFormat = "pdf"
+	// OutputFormatSVG writes one SVG file per output page.
+	OutputFormatSVG OutputFormat = "svg"
+)
+
+// ParseOutputFormat converts a string to an OutputFormat.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch OutputFormat(strings.ToLower(strings.TrimSpace(s))) {
+	case OutputFormatPNG:
+		return OutputFormatPNG, nil
+	case OutputFormatPDF:
+		return OutputFormatPDF, nil
+	case OutputFormatSVG:
+		return OutputFormatSVG, nil
+	default:
+		return "", fmt.Errorf("invalid output format: %s", s)
 	}
-	return images_, nil
 }
 
-// RenderOutputs renders all output pages and writes PNG files to outDir.
-// Returns the written file paths.
-func RenderOutputs(zl *zinelayout.ZineLayout, inputs []image.Image, outDir string) ([]string, error) {
+// RenderOptions controls which files RenderOutputs writes.
+type RenderOptions struct {
+	// Formats lists the output formats to write. Defaults to PNG only.
+	Formats []OutputFormat
+	// PDFName is the file name of the PDF written to outDir. Defaults to zine.pdf.
+	PDFName string
+	// SVGInputFiles are the paths of the input images. When set, the SVG
+	// files link to them by relative path instead of embedding the images.
+	SVGInputFiles []string
+	// Concurrency is the number of output pages rendered at the same time.
+	// Defaults to the number of CPUs.
+	Concurrency int
+	// Progress receives an event for every composed and encoded page and
+	// for the PDF. It may be nil.
+	Progress ProgressFunc
+}
+
+func (o RenderOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return runtime.NumCPU()
+	}
+	return o.Concurrency
+}
+
+func (o RenderOptions) hasFormat(f OutputFormat) bool {
+	if len(o.Formats) == 0 {
+		return f == OutputFormatPNG
+	}
+	for _, of := range o.Formats {
+		if of == f {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderOutputs renders all output pages and writes them to outDir in the
+// formats requested by opts. Returns the written file paths.
+//
+// Rendering stops with the error of ctx once ctx is cancelled. The files
+// written until then are removed, and so is outDir when RenderOutputs
+// created it.
+func RenderOutputs(ctx context.Context, zl *zinelayout.ZineLayout, inputs []image.Image, outDir string, opts RenderOptions) ([]string, error) {
+	_, statErr := os.Stat(outDir)
+	createdDir := errors.Is(statErr, fs.ErrNotExist)
 	if err := os.MkdirAll(outDir, 0o755); err != nil {
 		return nil, err
 	}
-	var written []string
-	for _, outputPage := range zl.OutputPages {
-		img, err := zl.CreateOutputImage(outputPage, inputs)
+	pw := &pageWriter{
+		zl:       zl,
+		inputs:   inputs,
+		outDir:   outDir,
+		png:      opts.hasFormat(OutputFormatPNG),
+		pdf:      opts.hasFormat(OutputFormatPDF),
+		svg:      opts.hasFormat(OutputFormatSVG),
+		progress: opts.Progress,
+	}
+	written, err := pw.renderAll(ctx, opts)
+	if err != nil && ctx.Err() != nil {
+		pw.removeFiles(createdDir)
+	}
+	return written, err
+}
+
+// renderAll renders all output pages of the layout and the PDF. Returns the
+// written paths in page order.
+func (pw *pageWriter) renderAll(ctx context.Context, opts RenderOptions) ([]string, error) {
+	zl := pw.zl
+	if pw.svg {
+		hrefs, err := relativeHrefs(pw.outDir, opts.SVGInputFiles)
 		if err != nil {
 			return nil, err
 		}
-		filePath := filepath.Join(outDir, outputPage.ID)
-		if !strings.HasSuffix(strings.ToLower(filePath), ".png") {
-			filePath += ".png"
+		pw.svgOpts.InputHrefs = hrefs
+	}
+
+	// The layout is only read from here on, by all workers
+	if err := zl.PrepareGeometry(); err != nil {
+		return nil, err
+	}
+	outputPages, err := zl.ExpandOutputPages(len(pw.inputs))
+	if err != nil {
+		return nil, err
+	}
+	pw.pages = len(outputPages)
+
+	// Every page fills its own slot, so that the written paths and the PDF
+	// pages keep the page order.
+	files := make([][]string, len(outputPages))
+	pages := make([]image.Image, len(outputPages))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.concurrency())
+	for i, outputPage := range outputPages {
+		g.Go(func() error {
+			// pages still waiting when the render was cancelled or another
+			// page failed are skipped
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			var err error
+			files[i], pages[i], err = pw.render(gctx, i, outputPage)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, f := range files {
+		written = append(written, f...)
+	}
+
+	if pw.pdf {
+		name := opts.PDFName
+		if name == "" {
+			name = "zine.pdf"
 		}
-		if err := writePNG(img, filePath); err != nil {
+		if !strings.HasSuffix(strings.ToLower(name), ".pdf") {
+			name += ".pdf"
+		}
+		filePath := pw.track(name)
+		start := time.Now()
+		if err := writePDF(ctx, pages, zl.Global.PPI, filePath); err != nil {
 			return nil, err
 		}
+		pw.progress.Report(PhaseEncodePDF, 1, 1, name, start)
 		written = append(written, filePath)
 	}
 	return written, nil
 }
 
-func writePNG(img image.Image, filename string) error {
+// pageWriter renders single output pages into the files of RenderOutputs.
+// It is shared by the workers, which only add to the created files.
+type pageWriter struct {
+	zl            *zinelayout.ZineLayout
+	inputs        []image.Image
+	outDir        string
+	png, pdf, svg bool
+	svgOpts       zinelayout.SVGOptions
+	progress      ProgressFunc
+	// pages is the number of output pages, for progress events.
+	pages int
+
+	mu      sync.Mutex
+	created []string
+}
+
+// track returns the path of the file name in the output directory and
+// remembers it for removeFiles.
+func (pw *pageWriter) track(name string) string {
+	filePath := filepath.Join(pw.outDir, name)
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.created = append(pw.created, filePath)
+	return filePath
+}
+
+// removeFiles removes the files of a cancelled render, or the whole output
+// directory when the render created it.
+func (pw *pageWriter) removeFiles(createdDir bool) {
+	if createdDir {
+		_ = os.RemoveAll(pw.outDir)
+		return
+	}
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	for _, filePath := range pw.created {
+		_ = os.Remove(filePath)
+	}
+}
+
+// render lays out the output page with the given index once and writes it
+// as SVG and PNG. Returns the written paths and, when a PDF is requested,
+// the page image.
+func (pw *pageWriter) render(ctx context.Context, index int, outputPage *zinelayout.OutputPage) ([]string, image.Image, error) {
+	start := time.Now()
+	plan, err := pw.zl.PlanPage(outputPage, pw.inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var img *image.RGBA
+	if pw.png || pw.pdf {
+		img, err = plan.Draw(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	pw.progress.Report(PhaseComposePage, index+1, pw.pages, outputPage.ID, start)
+
+	start = time.Now()
+	var written []string
+	if pw.svg {
+		name := outputPage.ID
+		if strings.HasSuffix(strings.ToLower(name), ".png") {
+			name = name[:len(name)-len(".png")]
+		}
+		filePath := pw.track(name + ".svg")
+		if err := writeSVG(ctx, plan, pw.svgOpts, filePath); err != nil {
+			return nil, nil, fmt.Errorf("output page %s: %w", outputPage.ID, err)
+		}
+		written = append(written, filePath)
+	}
+	if pw.png {
+		name := outputPage.ID
+		if !strings.HasSuffix(strings.ToLower(name), ".png") {
+			name += ".png"
+		}
+		filePath := pw.track(name)
+		if err := writePNG(ctx, img, filePath); err != nil {
+			return nil, nil, err
+		}
+		written = append(written, filePath)
+	}
+	pw.progress.Report(PhaseEncodePage, index+1, pw.pages, outputPage.ID, start)
+	if !pw.pdf {
+		return written, nil, nil
+	}
+	return written, img, nil
+}
+
+func writeSVG(ctx context.Context, plan *zinelayout.PagePlan, opts zinelayout.SVGOptions, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := plan.WriteSVG(contextWriter{ctx: ctx, w: f}, opts); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// relativeHrefs returns the paths of files relative to dir as URLs.
+func relativeHrefs(dir string, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	hrefs := make([]string, len(files))
+	for i, file := range files {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(absDir, absFile)
+		if err != nil {
+			return nil, err
+		}
+		hrefs[i] = (&url.URL{Path: filepath.ToSlash(rel)}).String()
+	}
+	return hrefs, nil
+}
+
+func writePNG(ctx context.Context, img image.Image, filename string) error {
 	f, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = f.Close() }()
-	return png.Encode(f, img)
+	return png.Encode(contextWriter{ctx: ctx, w: f}, img)
 }
 
 // ParseBorderColor accepts #hex, color names, or R,G,B,A
@@ -264,10 +577,19 @@ func GenerateTestImages(n, width, height int, bw bool) ([]image.Image, error) {
 func DebugPrintZineLayout(zl zinelayout.ZineLayout) {
 	fmt.Printf("PageSetup:\n")
 	fmt.Printf("  GridSize: Rows: %d, Columns: %d\n", zl.PageSetup.GridSize.Rows, zl.PageSetup.GridSize.Columns)
+	if len(zl.PageSetup.GridSize.ColumnWidths) > 0 || len(zl.PageSetup.GridSize.RowHeights) > 0 {
+		fmt.Printf("  Tracks: Columns: %v, Rows: %v\n", zl.PageSetup.GridSize.ColumnWidths, zl.PageSetup.GridSize.RowHeights)
+	}
 	fmt.Printf("  Margin: %+v\n", zl.PageSetup.Margin)
 	if zl.PageSetup.PageBorder != nil {
 		fmt.Printf("  PageBorder: Enabled: %v, Color: R:%d G:%d B:%d A:%d, Type: %s\n", zl.PageSetup.PageBorder.Enabled, zl.PageSetup.PageBorder.Color.R, zl.PageSetup.PageBorder.Color.G, zl.PageSetup.PageBorder.Color.B, zl.PageSetup.PageBorder.Color.A, zl.PageSetup.PageBorder.Type)
 	}
+	if zl.PageSetup.Paper != nil {
+		fmt.Printf("  Paper: Size: %s, Width: %s, Height: %s, Orientation: %s\n", zl.PageSetup.Paper.Size, zl.PageSetup.Paper.Width, zl.PageSetup.Paper.Height, zl.PageSetup.Paper.Orientation)
+	}
+	if zl.Signatures != nil {
+		fmt.Printf("  Signatures: Pages: %d, Count: %d\n", zl.Signatures.Pages, zl.Signatures.Count)
+	}
 	fmt.Printf("  PPI: %.0f\n", zl.Global.PPI)
 	fmt.Printf("OutputPages:\n")
 	for i, page := range zl.OutputPages {
@@ -281,8 +603,21 @@ func DebugPrintZineLayout(zl zinelayout.ZineLayout) {
 		for j, layout := range page.Layout {
 			fmt.Printf("      Layout %d:\n", j+1)
 			fmt.Printf("        InputIndex: %d\n", layout.InputIndex)
-			fmt.Printf("        Position: Row: %d, Column: %d\n", layout.Position.Row, layout.Position.Column)
+			if layout.X != "" || layout.Y != "" {
+				fmt.Printf("        Absolute: X: %s, Y: %s, Width: %s, Height: %s\n", layout.X, layout.Y, layout.Width, layout.Height)
+			} else {
+				fmt.Printf("        Position: Row: %d, Column: %d\n", layout.Position.Row, layout.Position.Column)
+			}
+			if layout.Position.RowSpan > 1 || layout.Position.ColumnSpan > 1 {
+				fmt.Printf("        Span: Rows: %d, Columns: %d\n", layout.Position.RowSpan, layout.Position.ColumnSpan)
+			}
 			fmt.Printf("        Rotation: %d\n", layout.Rotation)
+			if layout.Flip != "" {
+				fmt.Printf("        Flip: %s\n", layout.Flip)
+			}
+			if layout.Crop != nil {
+				fmt.Printf("        Crop: %+v\n", *layout.Crop)
+			}
 			fmt.Printf("        Margin: %+v\n", layout.Margin)
 			if layout.InnerLayoutBorder != nil {
 				fmt.Printf("        InnerLayoutBorder: Enabled: %v, Color: R:%d G:%d B:%d A:%d, Type: %s\n", layout.InnerLayoutBorder.Enabled, layout.InnerLayoutBorder.Color.R, layout.InnerLayoutBorder.Color.G, layout.InnerLayoutBorder.Color.B, layout.InnerLayoutBorder.Color.A, layout.InnerLayoutBorder.Type)
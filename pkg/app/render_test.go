@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-go-golems/zine-layout/pkg/zinelayout"
+)
+
+// testBooklet returns a four sheet booklet at a low PPI with its test
+// inputs.
+func testBooklet(t *testing.T) (*zinelayout.ZineLayout, []image.Image) {
+	t.Helper()
+	zl, err := zinelayout.ImposeSaddleStitch(zinelayout.ImposeOptions{Pages: 16, PagesPerSide: 2, PPI: 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inputs, err := GenerateTestImages(zl.InputCount(), 85, 110, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return zl, inputs
+}
+
+// renderCancelled renders testBooklet into outDir one page at a time and
+// cancels the render once the first page is written.
+func renderCancelled(t *testing.T, outDir string) {
+	t.Helper()
+	zl, inputs := testBooklet(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := RenderOptions{
+		Formats:     []OutputFormat{OutputFormatPNG, OutputFormatSVG, OutputFormatPDF},
+		Concurrency: 1,
+		Progress: func(ev ProgressEvent) {
+			if ev.Phase == PhaseEncodePage {
+				cancel()
+			}
+		},
+	}
+	written, err := RenderOutputs(ctx, zl, inputs, outDir, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, expected context.Canceled", err)
+	}
+	if written != nil {
+		t.Errorf("got written files %v for a cancelled render", written)
+	}
+}
+
+func TestRenderOutputsCancelledRemovesCreatedDir(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "out")
+	renderCancelled(t, outDir)
+	if _, err := os.Stat(outDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the output directory created by the render to be removed, got %v", err)
+	}
+}
+
+func TestRenderOutputsCancelledKeepsExistingFiles(t *testing.T) {
+	outDir := t.TempDir()
+	keep := filepath.Join(outDir, "keep.png")
+	if err := os.WriteFile(keep, []byte("not from the render"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	renderCancelled(t, outDir)
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatalf("expected the existing output directory to stay: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "keep.png" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("output directory holds %v, expected only keep.png", names)
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-go-golems/zine-layout/pkg/zinelayout"
+)
+
+// SplitPageName returns the file name of the nth split page. The numbers
+// are zero-padded so that sorting the names, as a shell glob does, keeps
+// the page order for ReadInputImages.
+func SplitPageName(prefix string, n int) string {
+	if prefix == "" {
+		return fmt.Sprintf("%04d.png", n)
+	}
+	return fmt.Sprintf("%s-%04d.png", prefix, n)
+}
+
+// SplitFiles cuts each input file into pages and writes them to outDir as
+// numbered PNG files, continuing the numbering from one input to the next.
+// Returns the written file paths in page order. Splitting stops with the
+// error of ctx once ctx is cancelled.
+func SplitFiles(ctx context.Context, files []string, outDir, prefix string, opts zinelayout.SplitOptions) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+	inputs, err := ReadInputImages(ctx, files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for i, img := range inputs {
+		pages, err := zinelayout.SplitImage(img, opts)
+		if err != nil {
+			return nil, fmt.Errorf("splitting %s: %w", files[i], err)
+		}
+		for _, page := range pages {
+			filePath := filepath.Join(outDir, SplitPageName(prefix, len(written)+1))
+			if err := writePNG(ctx, page, filePath); err != nil {
+				return nil, err
+			}
+			written = append(written, filePath)
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,74 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// isAbsolute reports whether the item is placed with x and y instead of a
+// grid position.
+func (l *Layout) isAbsolute() bool {
+	return strings.TrimSpace(l.X) != "" || strings.TrimSpace(l.Y) != ""
+}
+
+// checkSize reports a width or height on an item without x and y. Grid
+// items take the size of their cell, so the size would be ignored.
+func (l *Layout) checkSize() error {
+	if l.isAbsolute() {
+		return nil
+	}
+	if strings.TrimSpace(l.Width) != "" || strings.TrimSpace(l.Height) != "" {
+		return fmt.Errorf("width and height need x and y")
+	}
+	return nil
+}
+
+// absoluteRect returns the box of an absolutely placed item, measured from
+// the top left corner of the sheet. Without a width or height the box has
+// the natural size of the item.
+func (l *Layout) absoluteRect(natural image.Point, ppi float64) (image.Rectangle, error) {
+	if l.Position != (Position{}) {
+		return image.Rectangle{}, fmt.Errorf("set either a position or x and y")
+	}
+	values := []int{0, 0, natural.X, natural.Y}
+	for i, expr := range []string{l.X, l.Y, l.Width, l.Height} {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		px, err := ExpressionToPixels(expr, ppi)
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		values[i] = int(px)
+	}
+	if values[2] <= 0 || values[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("width and height must be positive, got %dx%d pixels", values[2], values[3])
+	}
+	return image.Rect(values[0], values[1], values[0]+values[2], values[1]+values[3]), nil
+}
+
+// checkAbsoluteItems reports absolute items that leave the sheet or
+// overlap another item. Overlaps between grid items are found by
+// checkOverlaps.
+func checkAbsoluteItems(layouts []*Layout, rects []image.Rectangle, sheet image.Rectangle) error {
+	for i, l := range layouts {
+		if !l.isAbsolute() {
+			continue
+		}
+		if !rects[i].In(sheet) {
+			return fmt.Errorf("input index %d at %v is outside the %dx%d sheet",
+				l.InputIndex, rects[i], sheet.Dx(), sheet.Dy())
+		}
+		for j, other := range layouts {
+			if j == i || (other.isAbsolute() && j < i) {
+				continue
+			}
+			if rects[i].Overlaps(rects[j]) {
+				return fmt.Errorf("input index %d at %v overlaps input index %d at %v",
+					l.InputIndex, rects[i], other.InputIndex, rects[j])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package zinelayout
+
+import (
+	"image"
+	"testing"
+)
+
+func TestAbsoluteRect(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   Layout
+		expected image.Rectangle
+	}{
+		{"natural size", Layout{X: "1in", Y: "0.5in"}, image.Rect(100, 50, 130, 90)},
+		{"sized", Layout{X: "1in + 10mm", Y: "0", Width: "0.5in", Height: "2in"}, image.Rect(139, 0, 189, 200)},
+	}
+	for _, tt := range tests {
+		got, err := tt.layout.absoluteRect(image.Pt(30, 40), 100)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: rect = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+
+	bad := Layout{Position: Position{Row: 1}, X: "1in"}
+	if _, err := bad.absoluteRect(image.Pt(1, 1), 100); err == nil {
+		t.Errorf("expected error for item with both position and x")
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   Layout
+		hasError bool
+	}{
+		{"grid item", Layout{Position: Position{Row: 1}}, false},
+		{"sized absolute item", Layout{X: "1in", Width: "2in", Height: "3in"}, false},
+		{"width without x and y", Layout{Width: "2in"}, true},
+		{"height without x and y", Layout{Position: Position{Column: 1}, Height: "3in"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.layout.checkSize(); (err != nil) != tt.hasError {
+			t.Errorf("%s: got error %v, expected error %v", tt.name, err, tt.hasError)
+		}
+	}
+
+	// PlanPage rejects the spec
+	zl := &ZineLayout{Global: &Global{PPI: 100}, PageSetup: &PageSetup{}}
+	zl.PageSetup.GridSize.Rows, zl.PageSetup.GridSize.Columns = 1, 1
+	page := &OutputPage{ID: "front", Layout: []*Layout{{InputIndex: 1, Width: "1in"}}}
+	if _, err := zl.PlanPage(page, []image.Image{image.NewRGBA(image.Rect(0, 0, 10, 10))}); err == nil {
+		t.Errorf("expected error for a width without x and y")
+	}
+}
+
+func TestCheckAbsoluteItems(t *testing.T) {
+	layouts := []*Layout{
+		{InputIndex: 1, Position: Position{Row: 0, Column: 0}},
+		{InputIndex: 2, X: "100px", Y: "0"},
+		{InputIndex: 3, X: "150px", Y: "0"},
+	}
+	sheet := image.Rect(0, 0, 300, 100)
+	rects := []image.Rectangle{
+		image.Rect(0, 0, 100, 100),
+		image.Rect(100, 0, 150, 100),
+		image.Rect(150, 0, 300, 100),
+	}
+	if err := checkAbsoluteItems(layouts, rects, sheet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rects[1] = image.Rect(90, 0, 150, 100)
+	if err := checkAbsoluteItems(layouts, rects, sheet); err == nil {
+		t.Errorf("expected error for absolute item overlapping a grid item")
+	}
+	rects[1] = image.Rect(100, 0, 160, 100)
+	if err := checkAbsoluteItems(layouts, rects, sheet); err == nil {
+		t.Errorf("expected error for overlapping absolute items")
+	}
+	rects[1] = image.Rect(100, 50, 150, 150)
+	if err := checkAbsoluteItems(layouts, rects, sheet); err == nil {
+		t.Errorf("expected error for item outside the sheet")
+	}
+}
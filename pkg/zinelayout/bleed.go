@@ -0,0 +1,190 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Bleed is the artwork that extends past the trim box of a layout item, so
+// that no white edge shows when the sheet is cut slightly off.
+type Bleed struct {
+	// Size is the bleed on each side of the trim box, as a unit expression.
+	Size string `yaml:"size"`
+	// Mirror extends the input by mirroring its edges into the bleed, for
+	// artwork that has no bleed of its own. Otherwise the input is expected
+	// to include the bleed around its trim area.
+	Mirror bool `yaml:"mirror,omitempty"`
+}
+
+// UnmarshalYAML accepts either a bleed mapping or a plain size expression.
+func (b *Bleed) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		b.Size = value.Value
+		b.Mirror = false
+		return nil
+	}
+	type rawBleed Bleed
+	var raw rawBleed
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	*b = Bleed(raw)
+	return nil
+}
+
+// CropMarks configures the marks drawn at the corners of each trim box.
+type CropMarks struct {
+	Enabled bool `yaml:"enabled"`
+	// Length of each mark, defaults to 5mm.
+	Length string `yaml:"length,omitempty"`
+	// Offset is the gap between the trim box and the start of a mark,
+	// defaults to 3mm. Marks never start inside the bleed.
+	Offset string      `yaml:"offset,omitempty"`
+	Color  CustomColor `yaml:"color,omitempty"`
+}
+
+// pixelValues returns the mark length and offset in pixels.
+func (cm *CropMarks) pixelValues(ppi float64) (int, int, error) {
+	length, offset := cm.Length, cm.Offset
+	if strings.TrimSpace(length) == "" {
+		length = "5mm"
+	}
+	if strings.TrimSpace(offset) == "" {
+		offset = "3mm"
+	}
+	l, err := ExpressionToPixels(length, ppi)
+	if err != nil {
+		return 0, 0, fmt.Errorf("crop mark length: %w", err)
+	}
+	o, err := ExpressionToPixels(offset, ppi)
+	if err != nil {
+		return 0, 0, fmt.Errorf("crop mark offset: %w", err)
+	}
+	if l <= 0 || o < 0 {
+		return 0, 0, fmt.Errorf("invalid crop mark length %s or offset %s", length, offset)
+	}
+	return int(l), int(o), nil
+}
+
+// bleedFor resolves the bleed of a layout item in pixels, falling back to
+// the page setup bleed.
+func (zl *ZineLayout) bleedFor(layout *Layout) (int, bool, error) {
+	bleed := layout.Bleed
+	if bleed == nil {
+		bleed = zl.PageSetup.Bleed
+	}
+	if bleed == nil || strings.TrimSpace(bleed.Size) == "" {
+		return 0, false, nil
+	}
+	px, err := ExpressionToPixels(bleed.Size, zl.Global.PPI)
+	if err != nil {
+		return 0, false, fmt.Errorf("bleed of input index %d: %w", layout.InputIndex, err)
+	}
+	if px < 0 {
+		return 0, false, fmt.Errorf("bleed of input index %d must not be negative, got %s", layout.InputIndex, bleed.Size)
+	}
+	return int(px), bleed.Mirror, nil
+}
+
+// mirrorExtend returns img grown by n pixels on every side, filling the new
+// border with a mirror image of the edges. The rows of img are copied and
+// extended sideways first, the rows above and below are copies of those.
+func mirrorExtend(img image.Image, n int) image.Image {
+	if n <= 0 {
+		return img
+	}
+	src := pixelsOf(img)
+	w, h, bpp := src.rect.Dx(), src.rect.Dy(), src.bpp
+	extended, dst := src.newPixels(image.Pt(w+2*n, h+2*n))
+
+	for y := 0; y < h; y++ {
+		from, to := src.row(y), dst.row(n+y)
+		copy(to[n*bpp:], from)
+		for x := 0; x < n; x++ {
+			l, r := reflectIndex(x-n, w), reflectIndex(w+x, w)
+			copy(to[x*bpp:(x+1)*bpp], from[l*bpp:(l+1)*bpp])
+			copy(to[(n+w+x)*bpp:(n+w+x+1)*bpp], from[r*bpp:(r+1)*bpp])
+		}
+	}
+	for y := 0; y < n; y++ {
+		copy(dst.row(y), dst.row(n+reflectIndex(y-n, h)))
+		copy(dst.row(n+h+y), dst.row(n+reflectIndex(h+y, h)))
+	}
+	return extended
+}
+
+// reflectIndex maps i onto [0, n) by mirroring at the edges.
+func reflectIndex(i, n int) int {
+	for i < 0 || i >= n {
+		if i < 0 {
+			i = -i - 1
+		}
+		if i >= n {
+			i = 2*n - i - 1
+		}
+	}
+	return i
+}
+
+// cropMarkRects returns the crop marks at the corners of each trim box as
+// one pixel wide rectangles, without the parts that would cover artwork.
+func cropMarkRects(trims []image.Rectangle, artwork []image.Rectangle, offset, length int) []image.Rectangle {
+	var marks []image.Rectangle
+	hline := func(x0, x1, y int) {
+		marks = append(marks, image.Rect(x0, y, x1, y+1))
+	}
+	vline := func(x, y0, y1 int) {
+		marks = append(marks, image.Rect(x, y0, x+1, y1))
+	}
+
+	for _, t := range trims {
+		left, right := t.Min.X, t.Max.X-1
+		top, bottom := t.Min.Y, t.Max.Y-1
+		// horizontal marks extend the top and bottom trim lines
+		hline(left-offset-length, left-offset, top)
+		hline(left-offset-length, left-offset, bottom)
+		hline(right+offset+1, right+offset+length+1, top)
+		hline(right+offset+1, right+offset+length+1, bottom)
+		// vertical marks extend the left and right trim lines
+		vline(left, top-offset-length, top-offset)
+		vline(right, top-offset-length, top-offset)
+		vline(left, bottom+offset+1, bottom+offset+length+1)
+		vline(right, bottom+offset+1, bottom+offset+length+1)
+	}
+
+	for _, hole := range artwork {
+		var kept []image.Rectangle
+		for _, m := range marks {
+			kept = append(kept, subtractRect(m, hole)...)
+		}
+		marks = kept
+	}
+	return marks
+}
+
+// subtractRect returns the parts of r outside hole.
+func subtractRect(r, hole image.Rectangle) []image.Rectangle {
+	if r.Empty() {
+		return nil
+	}
+	if !r.Overlaps(hole) {
+		return []image.Rectangle{r}
+	}
+	hole = hole.Intersect(r)
+	parts := []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, hole.Min.Y),
+		image.Rect(r.Min.X, hole.Max.Y, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, hole.Min.Y, hole.Min.X, hole.Max.Y),
+		image.Rect(hole.Max.X, hole.Min.Y, r.Max.X, hole.Max.Y),
+	}
+	var kept []image.Rectangle
+	for _, p := range parts {
+		if !p.Empty() {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
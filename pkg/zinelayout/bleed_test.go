@@ -0,0 +1,44 @@
+package zinelayout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBleedUnmarshal(t *testing.T) {
+	var ps PageSetup
+	if err := yaml.Unmarshal([]byte("bleed: 3mm"), &ps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ps.Bleed == nil || ps.Bleed.Size != "3mm" || ps.Bleed.Mirror {
+		t.Errorf("bleed = %+v, expected size 3mm", ps.Bleed)
+	}
+
+	var l Layout
+	if err := yaml.Unmarshal([]byte("bleed: { size: 0.125in, mirror: true }"), &l); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.Bleed == nil || l.Bleed.Size != "0.125in" || !l.Bleed.Mirror {
+		t.Errorf("bleed = %+v, expected mirrored 0.125in", l.Bleed)
+	}
+}
+
+func TestMirrorExtend(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	for x := 0; x < 3; x++ {
+		src.Set(x, 0, color.RGBA{R: uint8(x), A: 255})
+	}
+	dst := mirrorExtend(src, 2)
+	if got := dst.Bounds().Size(); got != image.Pt(7, 5) {
+		t.Fatalf("size = %v, expected (7,5)", got)
+	}
+	expected := []uint8{1, 0, 0, 1, 2, 2, 1}
+	for x, r := range expected {
+		if got := color.RGBAModel.Convert(dst.At(x, 4)).(color.RGBA).R; got != r {
+			t.Errorf("pixel %d = %d, expected %d", x, got, r)
+		}
+	}
+}
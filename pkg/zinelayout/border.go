@@ -16,7 +16,7 @@ const (
 )
 
 // drawBorder draws a border on the image based on the specified type
-func drawBorder(img *image.RGBA, rect image.Rectangle, c color.Color, borderType BorderType) {
+func drawBorder(img *image.RGBA, rect image.Rectangle, c color.RGBA, borderType BorderType) {
 	// If color is 0 0 0 0, make it black 0 0 0 255
 	if c == (color.RGBA{0, 0, 0, 0}) {
 		c = color.RGBA{0, 0, 0, 255}
@@ -34,45 +34,41 @@ func drawBorder(img *image.RGBA, rect image.Rectangle, c color.Color, borderType
 	}
 }
 
-func drawPlainBorder(img *image.RGBA, rect image.Rectangle, c color.Color) {
-	for x := rect.Min.X; x < rect.Max.X; x++ {
-		img.Set(x, rect.Min.Y, c)
-		img.Set(x, rect.Max.Y-1, c)
-	}
-	for y := rect.Min.Y; y < rect.Max.Y; y++ {
-		img.Set(rect.Min.X, y, c)
-		img.Set(rect.Max.X-1, y, c)
-	}
+func drawPlainBorder(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1), c)
+	fillRect(img, image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y), c)
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+1, rect.Max.Y), c)
+	fillRect(img, image.Rect(rect.Max.X-1, rect.Min.Y, rect.Max.X, rect.Max.Y), c)
 }
 
-func drawDottedBorder(img *image.RGBA, rect image.Rectangle, c color.Color) {
+func drawDottedBorder(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
 	for x := rect.Min.X; x < rect.Max.X; x += 2 {
-		img.Set(x, rect.Min.Y, c)
-		img.Set(x, rect.Max.Y-1, c)
+		img.SetRGBA(x, rect.Min.Y, c)
+		img.SetRGBA(x, rect.Max.Y-1, c)
 	}
 	for y := rect.Min.Y; y < rect.Max.Y; y += 2 {
-		img.Set(rect.Min.X, y, c)
-		img.Set(rect.Max.X-1, y, c)
+		img.SetRGBA(rect.Min.X, y, c)
+		img.SetRGBA(rect.Max.X-1, y, c)
 	}
 }
 
-func drawDashedBorder(img *image.RGBA, rect image.Rectangle, c color.Color) {
+func drawDashedBorder(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
 	dashLength := 4
 	for x := rect.Min.X; x < rect.Max.X; x++ {
 		if (x-rect.Min.X)%dashLength < dashLength/2 {
-			img.Set(x, rect.Min.Y, c)
-			img.Set(x, rect.Max.Y-1, c)
+			img.SetRGBA(x, rect.Min.Y, c)
+			img.SetRGBA(x, rect.Max.Y-1, c)
 		}
 	}
 	for y := rect.Min.Y; y < rect.Max.Y; y++ {
 		if (y-rect.Min.Y)%dashLength < dashLength/2 {
-			img.Set(rect.Min.X, y, c)
-			img.Set(rect.Max.X-1, y, c)
+			img.SetRGBA(rect.Min.X, y, c)
+			img.SetRGBA(rect.Max.X-1, y, c)
 		}
 	}
 }
 
-func drawCornerBorder(img *image.RGBA, rect image.Rectangle, c color.Color) {
+func drawCornerBorder(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
 	cornerLength := 20 // Length of corner dashes in pixels
 
 	// Top-left corner
@@ -92,7 +88,7 @@ func drawCornerBorder(img *image.RGBA, rect image.Rectangle, c color.Color) {
 	drawLine(img, rect.Max.X-1, rect.Max.Y-1, rect.Max.X-1, rect.Max.Y-1+cornerLength, c)
 }
 
-func drawLine(img *image.RGBA, x1, y1, x2, y2 int, c color.Color) {
+func drawLine(img *image.RGBA, x1, y1, x2, y2 int, c color.RGBA) {
 	bounds := img.Bounds()
 	dx := abs(x2 - x1)
 	dy := abs(y2 - y1)
@@ -107,7 +103,7 @@ func drawLine(img *image.RGBA, x1, y1, x2, y2 int, c color.Color) {
 
 	for {
 		if x1 >= bounds.Min.X && x1 < bounds.Max.X && y1 >= bounds.Min.Y && y1 < bounds.Max.Y {
-			img.Set(x1, y1, c)
+			img.SetRGBA(x1, y1, c)
 		}
 		if x1 == x2 && y1 == y2 {
 			return
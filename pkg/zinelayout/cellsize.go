@@ -0,0 +1,133 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// CellSizePolicy selects how grid cells are sized when no paper is set
+type CellSizePolicy string
+
+const (
+	// CellSizePolicyInput sizes each cell from the input placed in it.
+	CellSizePolicyInput CellSizePolicy = "input"
+	// CellSizePolicyMax uses the largest input width and height for every cell.
+	CellSizePolicyMax CellSizePolicy = "max"
+	// CellSizePolicyMin uses the smallest input width and height for every cell.
+	CellSizePolicyMin CellSizePolicy = "min"
+	// CellSizePolicyExplicit uses the width and height given in the spec.
+	CellSizePolicyExplicit CellSizePolicy = "explicit"
+)
+
+// CellSizing configures the size of grid cells. Sizes are given in the frame
+// of the input page and turn with rotated items, like their margins.
+type CellSizing struct {
+	Policy CellSizePolicy `yaml:"policy"`
+	Width  string         `yaml:"width,omitempty"`
+	Height string         `yaml:"height,omitempty"`
+}
+
+// uniformCellSize returns the cell size shared by all items, or false when
+// each cell takes the size of its own input.
+func (cs *CellSizing) uniformCellSize(inputImages []image.Image, ppi float64) (image.Point, bool, error) {
+	if cs == nil {
+		return image.Point{}, false, nil
+	}
+	policy := cs.Policy
+	if policy == "" && (cs.Width != "" || cs.Height != "") {
+		policy = CellSizePolicyExplicit
+	}
+
+	switch policy {
+	case "", CellSizePolicyInput:
+		return image.Point{}, false, nil
+	case CellSizePolicyMax, CellSizePolicyMin:
+		var size image.Point
+		for i, img := range inputImages {
+			s := img.Bounds().Size()
+			if i == 0 {
+				size = s
+				continue
+			}
+			if policy == CellSizePolicyMax {
+				size = image.Point{X: intMax(size.X, s.X), Y: intMax(size.Y, s.Y)}
+			} else {
+				size = image.Point{X: intMin(size.X, s.X), Y: intMin(size.Y, s.Y)}
+			}
+		}
+		return size, true, nil
+	case CellSizePolicyExplicit:
+		if strings.TrimSpace(cs.Width) == "" || strings.TrimSpace(cs.Height) == "" {
+			return image.Point{}, false, fmt.Errorf("explicit cell size needs both width and height")
+		}
+		w, err := ExpressionToPixels(cs.Width, ppi)
+		if err != nil {
+			return image.Point{}, false, fmt.Errorf("cell width: %w", err)
+		}
+		h, err := ExpressionToPixels(cs.Height, ppi)
+		if err != nil {
+			return image.Point{}, false, fmt.Errorf("cell height: %w", err)
+		}
+		if w <= 0 || h <= 0 {
+			return image.Point{}, false, fmt.Errorf("cell size must be positive, got %s x %s", cs.Width, cs.Height)
+		}
+		return image.Point{X: int(w), Y: int(h)}, true, nil
+	default:
+		return image.Point{}, false, fmt.Errorf("invalid cell size policy: %s", cs.Policy)
+	}
+}
+
+// Alignment positions an input inside its cell when it does not fill it.
+// It refers to the output sheet, not to the rotated input.
+type Alignment string
+
+const (
+	AlignCenter      Alignment = "center"
+	AlignTop         Alignment = "top"
+	AlignBottom      Alignment = "bottom"
+	AlignLeft        Alignment = "left"
+	AlignRight       Alignment = "right"
+	AlignTopLeft     Alignment = "top-left"
+	AlignTopRight    Alignment = "top-right"
+	AlignBottomLeft  Alignment = "bottom-left"
+	AlignBottomRight Alignment = "bottom-right"
+)
+
+// ParseAlignment converts a string to an Alignment
+func ParseAlignment(s string) (Alignment, error) {
+	switch a := Alignment(strings.ToLower(s)); a {
+	case AlignCenter, AlignTop, AlignBottom, AlignLeft, AlignRight,
+		AlignTopLeft, AlignTopRight, AlignBottomLeft, AlignBottomRight:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid alignment: %s", s)
+	}
+}
+
+// alignIn returns a rectangle of the given size placed inside rect.
+func alignIn(rect image.Rectangle, size image.Point, align Alignment) image.Rectangle {
+	x := rect.Min.X + (rect.Dx()-size.X)/2
+	y := rect.Min.Y + (rect.Dy()-size.Y)/2
+	a := string(align)
+	if strings.HasPrefix(a, "top") {
+		y = rect.Min.Y
+	}
+	if strings.HasPrefix(a, "bottom") {
+		y = rect.Max.Y - size.Y
+	}
+	if strings.HasSuffix(a, "left") {
+		x = rect.Min.X
+	}
+	if strings.HasSuffix(a, "right") {
+		x = rect.Max.X - size.X
+	}
+	return image.Rect(x, y, x+size.X, y+size.Y)
+}
+
+func intMin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
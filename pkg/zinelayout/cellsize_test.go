@@ -0,0 +1,90 @@
+package zinelayout
+
+import (
+	"image"
+	"testing"
+)
+
+func TestUniformCellSize(t *testing.T) {
+	inputs := []image.Image{
+		image.NewRGBA(image.Rect(0, 0, 100, 300)),
+		image.NewRGBA(image.Rect(0, 0, 250, 150)),
+		image.NewGray(image.Rect(10, 10, 160, 210)),
+	}
+
+	tests := []struct {
+		name     string
+		sizing   *CellSizing
+		expected image.Point
+		uniform  bool
+		hasError bool
+	}{
+		{"no cell size", nil, image.Point{}, false, false},
+		{"empty policy", &CellSizing{}, image.Point{}, false, false},
+		{"input", &CellSizing{Policy: CellSizePolicyInput}, image.Point{}, false, false},
+		{"max", &CellSizing{Policy: CellSizePolicyMax}, image.Pt(250, 300), true, false},
+		{"min", &CellSizing{Policy: CellSizePolicyMin}, image.Pt(100, 150), true, false},
+		{"explicit", &CellSizing{Policy: CellSizePolicyExplicit, Width: "2in", Height: "3in"}, image.Pt(200, 300), true, false},
+		{"explicit from width and height", &CellSizing{Width: "50px", Height: "1in + 20px"}, image.Pt(50, 120), true, false},
+		{"explicit without height", &CellSizing{Policy: CellSizePolicyExplicit, Width: "2in"}, image.Point{}, false, true},
+		{"explicit zero width", &CellSizing{Width: "0in", Height: "1in"}, image.Point{}, false, true},
+		{"explicit invalid width", &CellSizing{Width: "two inches", Height: "1in"}, image.Point{}, false, true},
+		{"invalid policy", &CellSizing{Policy: "average"}, image.Point{}, false, true},
+	}
+
+	for _, tt := range tests {
+		size, uniform, err := tt.sizing.uniformCellSize(inputs, 100)
+		if tt.hasError {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if size != tt.expected || uniform != tt.uniform {
+			t.Errorf("%s: got %v, %v, expected %v, %v", tt.name, size, uniform, tt.expected, tt.uniform)
+		}
+	}
+}
+
+func TestAlignIn(t *testing.T) {
+	rect := image.Rect(10, 20, 110, 80)
+	size := image.Pt(40, 20)
+
+	tests := []struct {
+		align    Alignment
+		expected image.Point // top-left corner of the placed rectangle
+	}{
+		{AlignCenter, image.Pt(40, 40)},
+		{AlignTop, image.Pt(40, 20)},
+		{AlignBottom, image.Pt(40, 60)},
+		{AlignLeft, image.Pt(10, 40)},
+		{AlignRight, image.Pt(70, 40)},
+		{AlignTopLeft, image.Pt(10, 20)},
+		{AlignTopRight, image.Pt(70, 20)},
+		{AlignBottomLeft, image.Pt(10, 60)},
+		{AlignBottomRight, image.Pt(70, 60)},
+	}
+
+	for _, tt := range tests {
+		a, err := ParseAlignment(string(tt.align))
+		if err != nil || a != tt.align {
+			t.Errorf("ParseAlignment(%q) = %q, %v", tt.align, a, err)
+		}
+		got := alignIn(rect, size, tt.align)
+		if expected := (image.Rectangle{Min: tt.expected, Max: tt.expected.Add(size)}); got != expected {
+			t.Errorf("alignIn(%s) = %v, expected %v", tt.align, got, expected)
+		}
+	}
+
+	// a larger item sticks out on the sides away from the alignment
+	if got := alignIn(rect, image.Pt(120, 80), AlignBottomRight); got != image.Rect(-10, 0, 110, 80) {
+		t.Errorf("alignIn of a larger item = %v, expected (-10,0)-(110,80)", got)
+	}
+	if _, err := ParseAlignment("middle"); err == nil {
+		t.Errorf("ParseAlignment(%q): expected error", "middle")
+	}
+}
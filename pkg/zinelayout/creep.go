@@ -0,0 +1,67 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Edge is a side of a grid cell on the output sheet
+type Edge string
+
+const (
+	EdgeLeft   Edge = "left"
+	EdgeRight  Edge = "right"
+	EdgeTop    Edge = "top"
+	EdgeBottom Edge = "bottom"
+)
+
+// ParseEdge converts a string to an Edge
+func ParseEdge(s string) (Edge, error) {
+	switch e := Edge(strings.ToLower(s)); e {
+	case EdgeLeft, EdgeRight, EdgeTop, EdgeBottom:
+		return e, nil
+	default:
+		return "", fmt.Errorf("invalid edge: %s", s)
+	}
+}
+
+// creepShift returns how far the input of a layout item is moved toward the
+// spine to compensate for creep. In a saddle-stitched booklet every sheet
+// wraps around the ones inside it, so the pages of a sheet at depth n stick
+// out by about n paper thicknesses once the booklet is trimmed.
+func (zl *ZineLayout) creepShift(layout *Layout) (image.Point, error) {
+	if strings.TrimSpace(zl.PageSetup.Creep) == "" || layout.Sheet == 0 {
+		return image.Point{}, nil
+	}
+	if layout.Sheet < 0 {
+		return image.Point{}, fmt.Errorf("sheet depth of input index %d must not be negative, got %d", layout.InputIndex, layout.Sheet)
+	}
+	if layout.Spine == "" {
+		return image.Point{}, fmt.Errorf("input index %d has a sheet depth but no spine edge", layout.InputIndex)
+	}
+	spine, err := ParseEdge(string(layout.Spine))
+	if err != nil {
+		return image.Point{}, fmt.Errorf("spine of input index %d: %w", layout.InputIndex, err)
+	}
+	thickness, err := ExpressionToPixels(zl.PageSetup.Creep, zl.Global.PPI)
+	if err != nil {
+		return image.Point{}, fmt.Errorf("creep: %w", err)
+	}
+	if thickness < 0 {
+		return image.Point{}, fmt.Errorf("creep must not be negative, got %s", zl.PageSetup.Creep)
+	}
+
+	shift := int(float64(layout.Sheet)*thickness + 0.5)
+	switch spine {
+	case EdgeLeft:
+		return image.Pt(-shift, 0), nil
+	case EdgeRight:
+		return image.Pt(shift, 0), nil
+	case EdgeTop:
+		return image.Pt(0, -shift), nil
+	case EdgeBottom:
+		return image.Pt(0, shift), nil
+	}
+	return image.Point{}, nil
+}
@@ -0,0 +1,68 @@
+package zinelayout
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCreepShift(t *testing.T) {
+	zl, err := ImposeSaddleStitch(ImposeOptions{Pages: 12, PagesPerSide: 2, Creep: "0.1in", PPI: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// innermost sheet: the left page moves right, the right page moves left
+	inner := zl.OutputPages[4]
+	expected := []image.Point{{X: 20}, {X: -20}}
+	for i, l := range inner.Layout {
+		shift, err := zl.creepShift(l)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if shift != expected[i] {
+			t.Errorf("shift of input %d = %v, expected %v", l.InputIndex, shift, expected[i])
+		}
+	}
+
+	shift, err := zl.creepShift(zl.OutputPages[0].Layout[0])
+	if err != nil || shift != (image.Point{}) {
+		t.Errorf("outermost sheet shift = %v, %v, expected none", shift, err)
+	}
+
+	if _, err := zl.creepShift(&Layout{InputIndex: 1, Sheet: 1}); err == nil {
+		t.Errorf("expected error for sheet depth without spine")
+	}
+}
+
+// TestCreepFolio checks that the folios of the innermost sheet move with the
+// creep of their inputs.
+func TestCreepFolio(t *testing.T) {
+	plans := make([]*PagePlan, 2)
+	for i, creep := range []string{"", "0.1in"} {
+		zl, err := ImposeSaddleStitch(ImposeOptions{Pages: 12, PagesPerSide: 2, Creep: creep, PPI: 100})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		zl.PageSetup.Folio = &Folio{Enabled: true}
+		inputs, err := GenerateTestImages(zl.InputCount(), 85, 110)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := zl.PrepareGeometry(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if plans[i], err = zl.PlanPage(zl.OutputPages[4], inputs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	flat, crept := plans[0], plans[1]
+	if len(flat.texts) != 2 || len(crept.texts) != 2 {
+		t.Fatalf("got %d and %d folios, expected 2", len(flat.texts), len(crept.texts))
+	}
+	expected := []image.Point{{X: 20}, {X: -20}}
+	for i, text := range crept.texts {
+		if shift := text.rect.Min.Sub(flat.texts[i].rect.Min); shift != expected[i] {
+			t.Errorf("folio %q moved by %v, expected %v", text.lines[0], shift, expected[i])
+		}
+	}
+}
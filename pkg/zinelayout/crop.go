@@ -0,0 +1,167 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+)
+
+// Crop removes pixels from an input before it is rotated and scaled, for
+// example the border a scanner leaves around a page.
+//
+// Either cut Top, Bottom, Left and Right off the edges, or select a
+// rectangle at X and Y from the top left corner of the input with an
+// optional Width and Height, which default to the rest of the input. All
+// values are unit expressions at the global PPI.
+type Crop struct {
+	Top    string `yaml:"top,omitempty" json:"top,omitempty"`
+	Bottom string `yaml:"bottom,omitempty" json:"bottom,omitempty"`
+	Left   string `yaml:"left,omitempty" json:"left,omitempty"`
+	Right  string `yaml:"right,omitempty" json:"right,omitempty"`
+	X      string `yaml:"x,omitempty" json:"x,omitempty"`
+	Y      string `yaml:"y,omitempty" json:"y,omitempty"`
+	Width  string `yaml:"width,omitempty" json:"width,omitempty"`
+	Height string `yaml:"height,omitempty" json:"height,omitempty"`
+}
+
+// IsZero reports whether the crop keeps the whole input.
+func (c *Crop) IsZero() bool {
+	return c == nil || *c == Crop{}
+}
+
+// rect returns the part of an input with the given bounds that is kept.
+func (c *Crop) rect(bounds image.Rectangle, ppi float64) (image.Rectangle, error) {
+	edges := []string{c.Top, c.Bottom, c.Left, c.Right}
+	box := []string{c.X, c.Y, c.Width, c.Height}
+	pixels := func(exprs []string) ([]int, bool, error) {
+		values := make([]int, len(exprs))
+		set := false
+		for i, expr := range exprs {
+			if strings.TrimSpace(expr) == "" {
+				values[i] = -1
+				continue
+			}
+			px, err := ExpressionToPixels(expr, ppi)
+			if err != nil {
+				return nil, false, err
+			}
+			if px < 0 {
+				return nil, false, fmt.Errorf("crop values must not be negative, got %s", expr)
+			}
+			values[i] = int(px)
+			set = true
+		}
+		return values, set, nil
+	}
+	e, byEdges, err := pixels(edges)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	b, byBox, err := pixels(box)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+
+	var r image.Rectangle
+	switch {
+	case byEdges && byBox:
+		return image.Rectangle{}, fmt.Errorf("crop either edges or a rectangle, not both")
+	case byEdges:
+		for i := range e {
+			e[i] = intMax(0, e[i])
+		}
+		r = image.Rect(bounds.Min.X+e[2], bounds.Min.Y+e[0], bounds.Max.X-e[3], bounds.Max.Y-e[1])
+	case byBox:
+		x, y := intMax(0, b[0]), intMax(0, b[1])
+		r = image.Rect(bounds.Min.X+x, bounds.Min.Y+y, bounds.Max.X, bounds.Max.Y)
+		if b[2] >= 0 {
+			r.Max.X = r.Min.X + b[2]
+		}
+		if b[3] >= 0 {
+			r.Max.Y = r.Min.Y + b[3]
+		}
+	default:
+		return bounds, nil
+	}
+	if r.Empty() || !r.In(bounds) {
+		return image.Rectangle{}, fmt.Errorf("crop %v does not fit the %dx%d input", r.Sub(bounds.Min), bounds.Dx(), bounds.Dy())
+	}
+	return r, nil
+}
+
+// Validate checks that the crop fits an input of the given pixel size at
+// ppi, with the same rules a render applies.
+func (c *Crop) Validate(size image.Point, ppi float64) error {
+	_, err := c.rect(image.Rectangle{Max: size}, ppi)
+	return err
+}
+
+// cropImage returns the part of img inside r. Images that support it share
+// their pixels with the result.
+func cropImage(img image.Image, r image.Rectangle) image.Image {
+	if r == img.Bounds() {
+		return img
+	}
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+// cropFor resolves the crop of a layout item, falling back to the default
+// crop of its input. It returns nil when the input is not cropped.
+func (zl *ZineLayout) cropFor(layout *Layout) *Crop {
+	if !layout.Crop.IsZero() {
+		return layout.Crop
+	}
+	if c := zl.PageSetup.Crops[layout.InputIndex]; !c.IsZero() {
+		return c
+	}
+	return nil
+}
+
+// cropInput returns the input image of a layout item with its crop applied.
+func (zl *ZineLayout) cropInput(layout *Layout, inputImages []image.Image) (image.Image, error) {
+	img := inputImages[layout.InputIndex-1]
+	r, err := zl.cropRect(layout, img)
+	if err != nil {
+		return nil, err
+	}
+	return cropImage(img, r), nil
+}
+
+// cropRect returns the part of the input image of a layout item that its
+// crop keeps.
+func (zl *ZineLayout) cropRect(layout *Layout, img image.Image) (image.Rectangle, error) {
+	c := zl.cropFor(layout)
+	if c == nil {
+		return img.Bounds(), nil
+	}
+	r, err := c.rect(img.Bounds(), zl.Global.PPI)
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("input index %d: %w", layout.InputIndex, err)
+	}
+	return r, nil
+}
+
+// cropDefaults returns the inputs with their default crops applied, for
+// sizing the grid.
+func (zl *ZineLayout) cropDefaults(inputImages []image.Image) ([]image.Image, error) {
+	if len(zl.PageSetup.Crops) == 0 {
+		return inputImages, nil
+	}
+	cropped := make([]image.Image, len(inputImages))
+	for i := range inputImages {
+		img, err := zl.cropInput(&Layout{InputIndex: i + 1}, inputImages)
+		if err != nil {
+			return nil, err
+		}
+		cropped[i] = img
+	}
+	return cropped, nil
+}
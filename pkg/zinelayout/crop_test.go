@@ -0,0 +1,93 @@
+package zinelayout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCropRect(t *testing.T) {
+	bounds := image.Rect(10, 10, 210, 310)
+	tests := []struct {
+		name     string
+		crop     Crop
+		expected image.Rectangle
+	}{
+		{"none", Crop{}, bounds},
+		{"edges", Crop{Top: "0.1in", Left: "5px", Right: "0.2in + 5px"}, image.Rect(15, 20, 185, 310)},
+		{"rectangle", Crop{X: "20px", Y: "0.5in", Width: "1in"}, image.Rect(30, 60, 130, 310)},
+	}
+	for _, tt := range tests {
+		got, err := tt.crop.rect(bounds, 100)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: rect = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+
+	for _, bad := range []Crop{
+		{Top: "1px", X: "1px"},
+		{Left: "1in", Right: "1in"},
+		{X: "1in", Width: "2in"},
+		{Top: "-1px"},
+	} {
+		if _, err := bad.rect(bounds, 100); err == nil {
+			t.Errorf("expected error for crop %+v", bad)
+		}
+	}
+}
+
+func TestCropValidate(t *testing.T) {
+	size := image.Pt(200, 300)
+	if err := (&Crop{Top: "1in", Width: "0.5in"}).Validate(size, 100); err == nil {
+		t.Errorf("expected error for a crop with edges and a rectangle")
+	}
+	if err := (&Crop{Bottom: "-2px"}).Validate(size, 100); err == nil {
+		t.Errorf("expected error for a negative crop")
+	}
+	if err := (&Crop{Y: "2in", Height: "2in"}).Validate(size, 100); err == nil {
+		t.Errorf("expected error for a crop larger than the input")
+	}
+	if err := (&Crop{Y: "2in", Height: "1in"}).Validate(size, 100); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCropFor(t *testing.T) {
+	zl := &ZineLayout{Global: &Global{PPI: 100}, PageSetup: &PageSetup{
+		Crops: map[int]*Crop{1: {Top: "10px"}},
+	}}
+	img := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	img.Set(0, 49, color.RGBA{255, 0, 0, 255})
+	inputs := []image.Image{img, img}
+
+	tests := []struct {
+		layout   *Layout
+		expected image.Rectangle
+	}{
+		{&Layout{InputIndex: 1}, image.Rect(0, 10, 50, 50)},
+		{&Layout{InputIndex: 1, Crop: &Crop{Bottom: "20px"}}, image.Rect(0, 0, 50, 30)},
+		{&Layout{InputIndex: 2}, image.Rect(0, 0, 50, 50)},
+	}
+	for _, tt := range tests {
+		got, err := zl.cropInput(tt.layout, inputs)
+		if err != nil {
+			t.Fatalf("input %d: unexpected error: %v", tt.layout.InputIndex, err)
+		}
+		if got.Bounds() != tt.expected {
+			t.Errorf("input %d: bounds = %v, expected %v", tt.layout.InputIndex, got.Bounds(), tt.expected)
+		}
+	}
+
+	// Rotation keeps the pixels of the cropped area only
+	cropped, _ := zl.cropInput(&Layout{InputIndex: 1}, inputs)
+	rotated := rotateImage(cropped, 90)
+	if rotated.Bounds().Size() != image.Pt(40, 50) {
+		t.Errorf("rotated size = %v, expected (40,50)", rotated.Bounds().Size())
+	}
+	if rotated.At(0, 0) != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("expected the bottom-left pixel in the top-left corner after rotation")
+	}
+}
@@ -0,0 +1,235 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Folio draws the logical page number of an input on its page.
+type Folio struct {
+	Enabled bool `yaml:"enabled"`
+	// Position of the number inside the trim box, in the frame of the input
+	// page. Defaults to bottom.
+	Position Alignment `yaml:"position,omitempty"`
+	// Odd and Even override Position for odd and even page numbers, for
+	// numbers on the outer corners.
+	Odd  Alignment `yaml:"odd,omitempty"`
+	Even Alignment `yaml:"even,omitempty"`
+	// Inset is the distance from the trim box edges, defaults to 0.25in.
+	Inset string `yaml:"inset,omitempty"`
+	// Font is an embedded font name or a font file, see LoadFont.
+	Font string `yaml:"font,omitempty"`
+	// Size is the font size as a unit expression, defaults to 10pt.
+	Size  string      `yaml:"size,omitempty"`
+	Color CustomColor `yaml:"color,omitempty"`
+	// Format is a fmt format for the number, defaults to "%d".
+	Format string `yaml:"format,omitempty"`
+	// Start is the page number of input 1, defaults to 1.
+	Start *int `yaml:"start,omitempty"`
+	// SkipFirst and SkipLast leave the first and last inputs (the covers)
+	// without a number. They still count for the numbering.
+	SkipFirst int `yaml:"skip_first,omitempty"`
+	SkipLast  int `yaml:"skip_last,omitempty"`
+	// Skip lists further page numbers to leave out.
+	Skip []int `yaml:"skip,omitempty"`
+}
+
+// folioFor resolves the folio of a layout item, falling back to the page
+// setup folio. It returns nil when no number is drawn.
+func (zl *ZineLayout) folioFor(layout *Layout) *Folio {
+	f := layout.Folio
+	if f == nil {
+		f = zl.PageSetup.Folio
+	}
+	if f == nil || !f.Enabled {
+		return nil
+	}
+	return f
+}
+
+// label returns the folio text and position for an input, or false when the
+// input is left without a number.
+func (f *Folio) label(inputIndex, inputCount int) (string, Alignment, bool, error) {
+	if inputIndex <= f.SkipFirst || inputIndex > inputCount-f.SkipLast {
+		return "", "", false, nil
+	}
+	start := 1
+	if f.Start != nil {
+		start = *f.Start
+	}
+	number := start + inputIndex - 1
+	for _, n := range f.Skip {
+		if n == number {
+			return "", "", false, nil
+		}
+	}
+
+	position := f.Position
+	if number%2 != 0 && f.Odd != "" {
+		position = f.Odd
+	}
+	if number%2 == 0 && f.Even != "" {
+		position = f.Even
+	}
+	if position == "" {
+		position = AlignBottom
+	}
+	align, err := ParseAlignment(string(position))
+	if err != nil {
+		return "", "", false, fmt.Errorf("folio position: %w", err)
+	}
+
+	format := f.Format
+	if format == "" {
+		format = "%d"
+	}
+	return fmt.Sprintf(format, number), align, true, nil
+}
+
+// place renders the folio of an input and places it in its trim box. The
+// position follows the flip and rotation of the input, the text only its
+// rotation so that it stays readable. It returns nil when the input is left
+// without a number.
+func (f *Folio) place(trim image.Rectangle, inputIndex, inputCount, rotation int, flip Flip, ppi float64) (*placedText, error) {
+	text, align, ok, err := f.label(inputIndex, inputCount)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	sizeExpr, insetExpr := f.Size, f.Inset
+	if strings.TrimSpace(sizeExpr) == "" {
+		sizeExpr = "10pt"
+	}
+	if strings.TrimSpace(insetExpr) == "" {
+		insetExpr = "0.25in"
+	}
+	size, err := ExpressionToPixels(sizeExpr, ppi)
+	if err != nil {
+		return nil, fmt.Errorf("folio size: %w", err)
+	}
+	inset, err := ExpressionToPixels(insetExpr, ppi)
+	if err != nil {
+		return nil, fmt.Errorf("folio inset: %w", err)
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("folio size must be at least one pixel, got %s", sizeExpr)
+	}
+
+	c := color.RGBA{0, 0, 0, 255}
+	if f.Color.RGBA != (color.RGBA{}) {
+		c = f.Color.RGBA
+	}
+	label, err := renderLabel(text, f.Font, size, c)
+	if err != nil {
+		return nil, err
+	}
+
+	label = rotateImage(label, rotation)
+	area := trim.Inset(int(inset))
+	return &placedText{
+		lines:    []string{text},
+		font:     f.Font,
+		size:     size,
+		color:    c,
+		align:    AlignLeft,
+		rotation: rotation,
+		block:    label,
+		rect:     alignIn(area, label.Bounds().Size(), rotateAlignment(flipAlignment(align, flip), rotation)),
+	}, nil
+}
+
+// renderLabel renders a line of text on a transparent image, with the
+// font at size pixels.
+func renderLabel(text, fontName string, size float64, c color.Color) (image.Image, error) {
+	face, err := newFontFacePixels(fontName, size)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = face.Close() }()
+
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	width := font.MeasureString(face, text).Ceil()
+	img := image.NewRGBA(image.Rect(0, 0, intMax(1, width), intMax(1, lineHeight)))
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(0, metrics.Ascent.Ceil()),
+	}
+	d.DrawString(text)
+
+	// the bitmap font only comes in one size
+	if strings.ToLower(fontName) != FontBasic || width == 0 || lineHeight == int(size) {
+		return img, nil
+	}
+	height := intMax(1, int(size))
+	return scaleImage(img, intMax(1, width*height/lineHeight), height), nil
+}
+
+// labelAscent returns the distance from the top of a label rendered by
+// renderLabel to the baseline of its text.
+func labelAscent(fontName string, size float64) (float64, error) {
+	face, err := newFontFacePixels(fontName, size)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = face.Close() }()
+
+	metrics := face.Metrics()
+	ascent := float64(metrics.Ascent.Ceil())
+	if strings.ToLower(fontName) == FontBasic {
+		// renderLabel scales the bitmap font to size
+		lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+		ascent *= float64(intMax(1, int(size))) / float64(lineHeight)
+	}
+	return ascent, nil
+}
+
+// flipAlignment returns the alignment of a point on the input page after
+// the page is mirrored by flip.
+func flipAlignment(align Alignment, flip Flip) Alignment {
+	switch flip {
+	case FlipHorizontal:
+		return Alignment(strings.NewReplacer("left", "right", "right", "left").Replace(string(align)))
+	case FlipVertical:
+		return Alignment(strings.NewReplacer("top", "bottom", "bottom", "top").Replace(string(align)))
+	case FlipNone:
+	}
+	return align
+}
+
+// rotateAlignment returns the alignment of a point on the input page after
+// the page is rotated clockwise by rotation degrees.
+func rotateAlignment(align Alignment, rotation int) Alignment {
+	a := string(align)
+	vertical, horizontal := "", ""
+	for _, part := range strings.Split(a, "-") {
+		switch part {
+		case "top", "bottom":
+			vertical = part
+		case "left", "right":
+			horizontal = part
+		}
+	}
+	for r := 0; r < rotation; r += 90 {
+		// a quarter turn moves the top to the right and the left to the top
+		next := map[string]string{"top": "right", "bottom": "left", "left": "top", "right": "bottom", "": ""}
+		vertical, horizontal = next[horizontal], next[vertical]
+	}
+	switch {
+	case vertical == "" && horizontal == "":
+		return AlignCenter
+	case vertical == "":
+		return Alignment(horizontal)
+	case horizontal == "":
+		return Alignment(vertical)
+	default:
+		return Alignment(vertical + "-" + horizontal)
+	}
+}
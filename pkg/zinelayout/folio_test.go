@@ -0,0 +1,109 @@
+package zinelayout
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFolioLabel(t *testing.T) {
+	zero := 0
+	f := &Folio{Enabled: true, Odd: AlignBottomRight, Even: AlignBottomLeft, SkipFirst: 1, SkipLast: 1, Start: &zero, Skip: []int{3}}
+	tests := []struct {
+		input    int
+		text     string
+		align    Alignment
+		numbered bool
+	}{
+		{1, "", "", false}, // front cover
+		{2, "1", AlignBottomRight, true},
+		{3, "2", AlignBottomLeft, true},
+		{4, "", "", false}, // skipped page 3
+		{8, "", "", false}, // back cover
+	}
+	for _, tt := range tests {
+		text, align, ok, err := f.label(tt.input, 8)
+		if err != nil {
+			t.Fatalf("input %d: unexpected error: %v", tt.input, err)
+		}
+		if ok != tt.numbered || text != tt.text || align != tt.align {
+			t.Errorf("input %d: got %q %s %v, expected %q %s %v", tt.input, text, align, ok, tt.text, tt.align, tt.numbered)
+		}
+	}
+}
+
+func TestRotateAlignment(t *testing.T) {
+	tests := []struct {
+		align    Alignment
+		rotation int
+		expected Alignment
+	}{
+		{AlignBottom, 0, AlignBottom},
+		{AlignBottom, 180, AlignTop},
+		{AlignBottomLeft, 90, AlignTopLeft},
+		{AlignBottomRight, 270, AlignTopRight},
+		{AlignCenter, 90, AlignCenter},
+	}
+	for _, tt := range tests {
+		if got := rotateAlignment(tt.align, tt.rotation); got != tt.expected {
+			t.Errorf("rotateAlignment(%s, %d) = %s, expected %s", tt.align, tt.rotation, got, tt.expected)
+		}
+	}
+}
+
+func TestFlipAlignment(t *testing.T) {
+	tests := []struct {
+		align    Alignment
+		flip     Flip
+		expected Alignment
+	}{
+		{AlignBottomRight, FlipNone, AlignBottomRight},
+		{AlignBottomRight, FlipHorizontal, AlignBottomLeft},
+		{AlignBottomRight, FlipVertical, AlignTopRight},
+		{AlignTop, FlipHorizontal, AlignTop},
+		{AlignLeft, FlipHorizontal, AlignRight},
+		{AlignCenter, FlipVertical, AlignCenter},
+	}
+	for _, tt := range tests {
+		if got := flipAlignment(tt.align, tt.flip); got != tt.expected {
+			t.Errorf("flipAlignment(%s, %q) = %s, expected %s", tt.align, tt.flip, got, tt.expected)
+		}
+	}
+}
+
+// TestFlippedFolio checks that the folio of a flipped input moves to the
+// mirrored corner of its trim box.
+func TestFlippedFolio(t *testing.T) {
+	zl, err := ImposeSaddleStitch(ImposeOptions{Pages: 4, PagesPerSide: 2, PPI: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zl.PageSetup.Folio = &Folio{Enabled: true, Position: AlignBottomRight, Inset: "0.1in"}
+	inputs, err := GenerateTestImages(zl.InputCount(), 85, 110)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := zl.PrepareGeometry(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page := zl.OutputPages[0]
+	page.Layout[1].Flip = FlipHorizontal
+	plan, err := zl.PlanPage(page, inputs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.texts) != 2 {
+		t.Fatalf("got %d folios, expected 2", len(plan.texts))
+	}
+
+	// the unflipped folio sits in the bottom right corner, the flipped one
+	// in the bottom left corner
+	expected := []image.Point{
+		{X: plan.items[0].trim.Max.X - 10 - plan.texts[0].rect.Dx(), Y: plan.items[0].trim.Max.Y - 10 - plan.texts[0].rect.Dy()},
+		{X: plan.items[1].trim.Min.X + 10, Y: plan.items[1].trim.Max.Y - 10 - plan.texts[1].rect.Dy()},
+	}
+	for i, text := range plan.texts {
+		if text.rect.Min != expected[i] {
+			t.Errorf("folio %q at %v, expected %v", text.lines[0], text.rect.Min, expected[i])
+		}
+	}
+}
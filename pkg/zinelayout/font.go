@@ -0,0 +1,104 @@
+package zinelayout
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// DefaultFont is the name of the embedded font used when none is given.
+const DefaultFont = "default"
+
+// FontBasic selects the 7x13 bitmap font, only meant for quick previews.
+// Its faces ignore the requested size; folios and text elements scale the
+// rendered text to it instead.
+const FontBasic = "basic"
+
+// embeddedFonts maps the names of the built-in fonts to their TTF data.
+var embeddedFonts = map[string][]byte{
+	DefaultFont: goregular.TTF,
+	"regular":   goregular.TTF,
+	"bold":      gobold.TTF,
+	"italic":    goitalic.TTF,
+	"mono":      gomono.TTF,
+}
+
+// parsedFonts caches parsed fonts by name or file path.
+var parsedFonts sync.Map
+
+// LoadFont returns the font with the given name: one of the embedded fonts
+// (default, regular, bold, italic, mono) or the path of a TTF, OTF or font
+// collection file, of which the first font is used.
+func LoadFont(name string) (*sfnt.Font, error) {
+	if name == "" {
+		name = DefaultFont
+	}
+	if f, ok := parsedFonts.Load(name); ok {
+		return f.(*sfnt.Font), nil
+	}
+
+	data, ok := embeddedFonts[strings.ToLower(name)]
+	if !ok {
+		var err error
+		data, err = os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("loading font %s: %w", name, err)
+		}
+	}
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing font %s: %w", name, err)
+	}
+	f, err := collection.Font(0)
+	if err != nil {
+		return nil, fmt.Errorf("parsing font %s: %w", name, err)
+	}
+	parsedFonts.Store(name, f)
+	return f, nil
+}
+
+// NewFontFace returns a face of the named font at size, a unit expression
+// such as "10pt" or "4mm", rendered at the given PPI. The caller should
+// close the face.
+func NewFontFace(name, size string, ppi float64) (font.Face, error) {
+	px, err := ExpressionToPixels(size, ppi)
+	if err != nil {
+		return nil, fmt.Errorf("font size: %w", err)
+	}
+	if px <= 0 {
+		return nil, fmt.Errorf("font size must be positive, got %s", size)
+	}
+	return newFontFacePixels(name, px)
+}
+
+// newFontFacePixels returns a face of the named font with an em size of px
+// pixels. The basic font keeps its 7x13 size.
+func newFontFacePixels(name string, px float64) (font.Face, error) {
+	if strings.ToLower(name) == FontBasic {
+		return basicfont.Face7x13, nil
+	}
+	f, err := LoadFont(name)
+	if err != nil {
+		return nil, err
+	}
+	// at 72 DPI one point is one pixel
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    px,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating face for font %s: %w", name, err)
+	}
+	return face, nil
+}
@@ -0,0 +1,27 @@
+package zinelayout
+
+import (
+	"testing"
+)
+
+func TestNewFontFace(t *testing.T) {
+	face, err := NewFontFace("", "12pt", 300)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = face.Close() }()
+	// 12pt at 300 PPI is 50 pixels per em
+	if h := face.Metrics().Height.Ceil(); h < 50 || h > 70 {
+		t.Errorf("line height = %d, expected about 50-70 pixels", h)
+	}
+
+	if _, err := NewFontFace("bold", "10pt", 300); err != nil {
+		t.Errorf("unexpected error for embedded bold font: %v", err)
+	}
+	if _, err := NewFontFace("does-not-exist.ttf", "10pt", 300); err == nil {
+		t.Errorf("expected error for missing font file")
+	}
+	if _, err := NewFontFace("", "0pt", 300); err == nil {
+		t.Errorf("expected error for zero size")
+	}
+}
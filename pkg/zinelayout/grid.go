@@ -0,0 +1,244 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rowSpan returns the number of rows covered, at least 1.
+func (p Position) rowSpan() int {
+	return intMax(1, p.RowSpan)
+}
+
+// columnSpan returns the number of columns covered, at least 1.
+func (p Position) columnSpan() int {
+	return intMax(1, p.ColumnSpan)
+}
+
+// trackKind is how a grid track is sized.
+type trackKind int
+
+const (
+	// trackAuto tracks are as large as their content.
+	trackAuto trackKind = iota
+	// trackFixed tracks have a size given as a unit expression.
+	trackFixed
+	// trackFraction tracks share the space left by the other tracks.
+	trackFraction
+)
+
+// trackSpec is a parsed entry of GridSize.ColumnWidths or RowHeights.
+type trackSpec struct {
+	kind trackKind
+	size int     // pixels of a fixed track
+	fr   float64 // share of a fraction track
+}
+
+// parseTrackSpecs parses count track sizes: "auto", a fraction such as
+// "1fr" or "2.5fr", or a unit expression. Without sizes every track is
+// fallback.
+func parseTrackSpecs(exprs []string, count int, fallback trackSpec, ppi float64) ([]trackSpec, error) {
+	specs := make([]trackSpec, count)
+	if len(exprs) == 0 {
+		for i := range specs {
+			specs[i] = fallback
+		}
+		return specs, nil
+	}
+	if len(exprs) != count {
+		return nil, fmt.Errorf("%d track sizes given for %d tracks", len(exprs), count)
+	}
+	for i, expr := range exprs {
+		expr = strings.TrimSpace(strings.ToLower(expr))
+		switch {
+		case expr == "auto":
+			specs[i] = trackSpec{kind: trackAuto}
+		case strings.HasSuffix(expr, "fr"):
+			fr, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(expr, "fr")), 64)
+			if err != nil || fr <= 0 {
+				return nil, fmt.Errorf("invalid fraction track size: %s", exprs[i])
+			}
+			specs[i] = trackSpec{kind: trackFraction, fr: fr}
+		default:
+			px, err := ExpressionToPixels(expr, ppi)
+			if err != nil {
+				return nil, fmt.Errorf("track size %s: %w", exprs[i], err)
+			}
+			if px < 0 {
+				return nil, fmt.Errorf("track size must not be negative, got %s", exprs[i])
+			}
+			specs[i] = trackSpec{kind: trackFixed, size: int(px)}
+		}
+	}
+	return specs, nil
+}
+
+// trackRequest asks for size pixels across span tracks starting at start.
+type trackRequest struct {
+	start int
+	span  int
+	size  int
+}
+
+// sizeTracks returns the pixel sizes of the tracks described by specs.
+//
+// Fixed tracks keep their size. Auto tracks are at least minSize and fit
+// the requests of the items in them. When available is not negative, the
+// fraction tracks share what the other tracks leave of it; otherwise one
+// fraction is made large enough for the content of every fraction track.
+// Items spanning several tracks then grow the auto tracks they cover
+// evenly, or their fraction tracks when the available space is open.
+func sizeTracks(specs []trackSpec, minSize int, requests []trackRequest, available int) ([]int, error) {
+	sizes := make([]int, len(specs))
+	totalFr := 0.0
+	for i, spec := range specs {
+		switch spec.kind {
+		case trackFixed:
+			sizes[i] = spec.size
+		case trackAuto:
+			sizes[i] = minSize
+		case trackFraction:
+			totalFr += spec.fr
+		}
+	}
+	for _, r := range requests {
+		if r.span == 1 && specs[r.start].kind == trackAuto {
+			sizes[r.start] = intMax(sizes[r.start], r.size)
+		}
+	}
+
+	if totalFr > 0 {
+		if available >= 0 {
+			free := available
+			for i, spec := range specs {
+				if spec.kind != trackFraction {
+					free -= sizes[i]
+				}
+			}
+			if free < 0 {
+				return nil, fmt.Errorf("grid tracks need %d more pixels than the %d available", -free, available)
+			}
+			// The last fraction track takes the rounding remainder
+			last, used := 0, 0
+			for i, spec := range specs {
+				if spec.kind == trackFraction {
+					sizes[i] = int(float64(free) * spec.fr / totalFr)
+					used += sizes[i]
+					last = i
+				}
+			}
+			sizes[last] += free - used
+		} else {
+			unit := 0.0
+			for i, spec := range specs {
+				if spec.kind == trackFraction {
+					unit = math.Max(unit, float64(minSize)/spec.fr)
+					for _, r := range requests {
+						if r.span == 1 && r.start == i {
+							unit = math.Max(unit, float64(r.size)/spec.fr)
+						}
+					}
+				}
+			}
+			for i, spec := range specs {
+				if spec.kind == trackFraction {
+					sizes[i] = int(math.Ceil(unit * spec.fr))
+				}
+			}
+		}
+	}
+
+	for _, r := range requests {
+		if r.span == 1 {
+			continue
+		}
+		total := 0
+		var growable []int
+		for i := r.start; i < r.start+r.span; i++ {
+			total += sizes[i]
+			if specs[i].kind == trackAuto || (specs[i].kind == trackFraction && available < 0) {
+				growable = append(growable, i)
+			}
+		}
+		missing := r.size - total
+		for n, i := range growable {
+			if missing <= 0 {
+				break
+			}
+			// spread the remainder over the first tracks
+			grow := (missing + len(growable) - n - 1) / (len(growable) - n)
+			sizes[i] += grow
+			missing -= grow
+		}
+	}
+
+	if available >= 0 {
+		total := 0
+		for _, size := range sizes {
+			total += size
+		}
+		if total > available {
+			return nil, fmt.Errorf("grid tracks need %d pixels, only %d available", total, available)
+		}
+	}
+	return sizes, nil
+}
+
+// newGridLines places tracks of the given sizes from origin.
+func newGridLines(origin image.Point, columnWidths, rowHeights []int) gridLines {
+	g := gridLines{columns: []int{origin.X}, rows: []int{origin.Y}}
+	for _, w := range columnWidths {
+		g.columns = append(g.columns, g.columns[len(g.columns)-1]+w)
+	}
+	for _, h := range rowHeights {
+		g.rows = append(g.rows, g.rows[len(g.rows)-1]+h)
+	}
+	return g
+}
+
+// rect returns the area covered by a position, including its spans.
+func (g gridLines) rect(p Position) image.Rectangle {
+	return image.Rect(
+		g.columns[p.Column], g.rows[p.Row],
+		g.columns[p.Column+p.columnSpan()], g.rows[p.Row+p.rowSpan()],
+	)
+}
+
+// checkPosition verifies that a position and its spans fit a grid.
+func checkPosition(p Position, rows, columns int) error {
+	if p.RowSpan < 0 || p.ColumnSpan < 0 {
+		return fmt.Errorf("spans must not be negative, got %d rows and %d columns", p.RowSpan, p.ColumnSpan)
+	}
+	if p.Row < 0 || p.Row+p.rowSpan() > rows || p.Column < 0 || p.Column+p.columnSpan() > columns {
+		return fmt.Errorf("position (%d, %d) spanning %dx%d cells is outside the %dx%d grid",
+			p.Row, p.Column, p.rowSpan(), p.columnSpan(), rows, columns)
+	}
+	return nil
+}
+
+// checkOverlaps reports grid items whose cells overlap.
+func checkOverlaps(layouts []*Layout, rows, columns int) error {
+	owner := make([][]int, rows)
+	for r := range owner {
+		owner[r] = make([]int, columns)
+	}
+	for i, l := range layouts {
+		if l.isAbsolute() {
+			continue
+		}
+		p := l.Position
+		for r := p.Row; r < p.Row+p.rowSpan(); r++ {
+			for c := p.Column; c < p.Column+p.columnSpan(); c++ {
+				if j := owner[r][c]; j != 0 {
+					return fmt.Errorf("input index %d overlaps input index %d at cell (%d, %d)",
+						l.InputIndex, layouts[j-1].InputIndex, r, c)
+				}
+				owner[r][c] = i + 1
+			}
+		}
+	}
+	return nil
+}
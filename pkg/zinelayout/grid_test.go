@@ -0,0 +1,147 @@
+package zinelayout
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSizeTracks(t *testing.T) {
+	auto := trackSpec{kind: trackAuto}
+	autos := []trackSpec{auto, auto, auto}
+	tests := []struct {
+		name      string
+		specs     []trackSpec
+		min       int
+		requests  []trackRequest
+		available int
+		expected  []int
+	}{
+		{"single", autos, 0, []trackRequest{{0, 1, 100}, {1, 1, 50}, {1, 1, 80}}, -1, []int{100, 80, 0}},
+		{"minimum", autos, 60, []trackRequest{{0, 1, 100}}, -1, []int{100, 60, 60}},
+		{"span fits", autos, 0, []trackRequest{{0, 1, 100}, {1, 1, 100}, {0, 2, 150}}, -1, []int{100, 100, 0}},
+		{"span grows", autos, 0, []trackRequest{{0, 1, 100}, {1, 1, 100}, {0, 2, 301}}, -1, []int{151, 150, 0}},
+		{"span over empty", autos, 0, []trackRequest{{0, 3, 300}}, -1, []int{100, 100, 100}},
+		{
+			"even split",
+			[]trackSpec{{kind: trackFraction, fr: 1}, {kind: trackFraction, fr: 1}, {kind: trackFraction, fr: 1}},
+			0, nil, 100, []int{33, 33, 34},
+		},
+		{
+			"fixed, auto and fractions",
+			[]trackSpec{{kind: trackFixed, size: 50}, auto, {kind: trackFraction, fr: 2}},
+			0, []trackRequest{{1, 1, 70}, {2, 1, 500}}, 300, []int{50, 70, 180},
+		},
+		{
+			"open fractions fit content",
+			[]trackSpec{{kind: trackFraction, fr: 1}, {kind: trackFraction, fr: 2}, {kind: trackFixed, size: 10}},
+			0, []trackRequest{{0, 1, 100}, {1, 1, 120}, {2, 1, 500}}, -1, []int{100, 200, 10},
+		},
+		{
+			"span grows auto only",
+			[]trackSpec{{kind: trackFixed, size: 50}, auto, auto},
+			0, []trackRequest{{0, 3, 150}}, -1, []int{50, 50, 50},
+		},
+	}
+	for _, tt := range tests {
+		got, err := sizeTracks(tt.specs, tt.min, tt.requests, tt.available)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: sizes = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+
+	fixed := []trackSpec{{kind: trackFixed, size: 80}, {kind: trackFraction, fr: 1}}
+	if _, err := sizeTracks(fixed, 0, nil, 50); err == nil {
+		t.Errorf("expected error for tracks larger than the available space")
+	}
+}
+
+func TestParseTrackSpecs(t *testing.T) {
+	specs, err := parseTrackSpecs([]string{"1in + 10px", "auto", "2fr", "0.5 fr"}, 4, trackSpec{}, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []trackSpec{
+		{kind: trackFixed, size: 110},
+		{kind: trackAuto},
+		{kind: trackFraction, fr: 2},
+		{kind: trackFraction, fr: 0.5},
+	}
+	if !reflect.DeepEqual(specs, expected) {
+		t.Errorf("specs = %v, expected %v", specs, expected)
+	}
+
+	for _, bad := range [][]string{{"1in"}, {"auto", "0fr"}, {"auto", "wide"}} {
+		if _, err := parseTrackSpecs(bad, 2, trackSpec{}, 100); err == nil {
+			t.Errorf("expected error for %v", bad)
+		}
+	}
+}
+
+func TestCheckOverlaps(t *testing.T) {
+	layouts := []*Layout{
+		{InputIndex: 1, Position: Position{Row: 0, Column: 0, ColumnSpan: 2}},
+		{InputIndex: 2, Position: Position{Row: 1, Column: 0}},
+		{InputIndex: 3, Position: Position{Row: 1, Column: 1}},
+	}
+	if err := checkOverlaps(layouts, 2, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	layouts[2].Position = Position{Row: 0, Column: 1}
+	if err := checkOverlaps(layouts, 2, 2); err == nil {
+		t.Errorf("expected overlap error")
+	}
+
+	if err := checkPosition(Position{Row: 1, Column: 1, ColumnSpan: 2}, 2, 2); err == nil {
+		t.Errorf("expected error for span outside the grid")
+	}
+}
+
+func TestCreateOutputImageSpan(t *testing.T) {
+	var zl ZineLayout
+	spec := `
+global: { ppi: 100 }
+page_setup:
+  grid_size: { rows: 2, columns: 2 }
+output_pages:
+  - id: centerfold
+    layout:
+      - input_index: 1
+        position: { row: 0, column: 0, column_span: 2 }
+      - input_index: 2
+        position: { row: 1, column: 0 }
+      - input_index: 3
+        position: { row: 1, column: 1 }
+`
+	if err := yaml.Unmarshal([]byte(spec), &zl); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spread := image.NewRGBA(image.Rect(0, 0, 200, 50))
+	page1 := image.NewRGBA(image.Rect(0, 0, 100, 80))
+	page2 := image.NewRGBA(image.Rect(0, 0, 100, 80))
+	for i, img := range []*image.RGBA{spread, page1, page2} {
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{uint8(50 * (i + 1)), 0, 0, 255}), image.Point{}, draw.Src)
+	}
+
+	out, err := zl.CreateOutputImage(context.Background(), zl.OutputPages[0], []image.Image{spread, page1, page2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Bounds().Size() != image.Pt(200, 130) {
+		t.Fatalf("size = %v, expected (200,130)", out.Bounds().Size())
+	}
+	if r, _, _, _ := out.At(150, 25).RGBA(); r>>8 != 50 {
+		t.Errorf("spread not drawn across both columns, red = %d", r>>8)
+	}
+	if r, _, _, _ := out.At(150, 100).RGBA(); r>>8 != 150 {
+		t.Errorf("input 3 not in the second column, red = %d", r>>8)
+	}
+}
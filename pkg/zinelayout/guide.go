@@ -0,0 +1,212 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// GuideType is the kind of line a guide marks on the sheet
+type GuideType string
+
+const (
+	// GuideTypeFold is drawn as a dashed line.
+	GuideTypeFold GuideType = "fold"
+	// GuideTypeCut is drawn as a solid line with a scissor mark at its start.
+	GuideTypeCut GuideType = "cut"
+)
+
+// GuideOrientation is the direction a guide runs in
+type GuideOrientation string
+
+const (
+	GuideHorizontal GuideOrientation = "horizontal"
+	GuideVertical   GuideOrientation = "vertical"
+)
+
+// Guide is a fold or cut line drawn on an output page.
+//
+// It is placed either on a GridLine (0 is the top or left edge of the grid,
+// rows or columns the bottom or right edge) or at a Position given as a unit
+// expression from the top or left edge of the sheet. From and To limit the
+// guide to a range of grid lines across it; by default it runs across the
+// whole sheet.
+type Guide struct {
+	Type        GuideType        `yaml:"type"`
+	Orientation GuideOrientation `yaml:"orientation"`
+	GridLine    *int             `yaml:"grid_line,omitempty"`
+	Position    string           `yaml:"position,omitempty"`
+	From        *int             `yaml:"from,omitempty"`
+	To          *int             `yaml:"to,omitempty"`
+	// Width is the line width as a unit expression, defaults to 0.5pt.
+	Width string      `yaml:"width,omitempty"`
+	Color CustomColor `yaml:"color,omitempty"`
+}
+
+// gridLines holds the pixel coordinates of the grid lines of an output page.
+type gridLines struct {
+	columns []int // x of each vertical grid line, left to right
+	rows    []int // y of each horizontal grid line, top to bottom
+}
+
+// guideLine is a guide resolved to pixels. It runs from start to end along
+// its orientation and is width pixels wide, centered on pos.
+type guideLine struct {
+	kind        GuideType
+	orientation GuideOrientation
+	pos         int
+	start, end  int
+	width       int
+	color       color.RGBA
+	// dash and gap are the pattern of fold lines
+	dash, gap int
+	// scissors is the size of the mark at the start of cut lines
+	scissors int
+}
+
+// resolveGuides resolves the guides of an output page with the given sheet
+// bounds to pixels.
+func resolveGuides(guides []*Guide, lines gridLines, bounds image.Rectangle, ppi float64) ([]guideLine, error) {
+	resolved := make([]guideLine, 0, len(guides))
+	for i, g := range guides {
+		var across, along []int
+		var length int
+		orientation := GuideOrientation(strings.ToLower(string(g.Orientation)))
+		switch orientation {
+		case GuideHorizontal:
+			across, along, length = lines.rows, lines.columns, bounds.Dx()
+		case GuideVertical:
+			across, along, length = lines.columns, lines.rows, bounds.Dy()
+		default:
+			return nil, fmt.Errorf("guide %d: invalid orientation: %s", i+1, g.Orientation)
+		}
+
+		var pos int
+		switch {
+		case g.GridLine != nil && g.Position != "":
+			return nil, fmt.Errorf("guide %d: set either grid_line or position", i+1)
+		case g.GridLine != nil:
+			if *g.GridLine < 0 || *g.GridLine >= len(across) {
+				return nil, fmt.Errorf("guide %d: grid line %d out of range 0-%d", i+1, *g.GridLine, len(across)-1)
+			}
+			pos = across[*g.GridLine]
+		case g.Position != "":
+			p, err := ExpressionToPixels(g.Position, ppi)
+			if err != nil {
+				return nil, fmt.Errorf("guide %d: position: %w", i+1, err)
+			}
+			pos = int(p)
+		default:
+			return nil, fmt.Errorf("guide %d: needs a grid_line or a position", i+1)
+		}
+
+		start, end := 0, length
+		if g.From != nil {
+			if *g.From < 0 || *g.From >= len(along) {
+				return nil, fmt.Errorf("guide %d: from grid line %d out of range 0-%d", i+1, *g.From, len(along)-1)
+			}
+			start = along[*g.From]
+		}
+		if g.To != nil {
+			if *g.To < 0 || *g.To >= len(along) {
+				return nil, fmt.Errorf("guide %d: to grid line %d out of range 0-%d", i+1, *g.To, len(along)-1)
+			}
+			end = along[*g.To]
+		}
+		if end <= start {
+			return nil, fmt.Errorf("guide %d: empty range", i+1)
+		}
+
+		widthExpr := g.Width
+		if strings.TrimSpace(widthExpr) == "" {
+			widthExpr = "0.5pt"
+		}
+		w, err := ExpressionToPixels(widthExpr, ppi)
+		if err != nil {
+			return nil, fmt.Errorf("guide %d: width: %w", i+1, err)
+		}
+
+		c := color.RGBA{0, 0, 0, 255}
+		if g.Color.RGBA != (color.RGBA{}) {
+			c = g.Color.RGBA
+		}
+
+		kind := GuideType(strings.ToLower(string(g.Type)))
+		switch kind {
+		case GuideTypeFold, GuideTypeCut:
+		default:
+			return nil, fmt.Errorf("guide %d: invalid type: %s", i+1, g.Type)
+		}
+		resolved = append(resolved, guideLine{
+			kind:        kind,
+			orientation: orientation,
+			pos:         pos,
+			start:       start,
+			end:         end,
+			width:       intMax(1, int(math.Round(w))),
+			color:       c,
+			dash:        intMax(2, int(ppi/10)),
+			gap:         intMax(1, int(ppi/20)),
+			scissors:    intMax(12, int(ppi/5)),
+		})
+	}
+	return resolved, nil
+}
+
+func (g guideLine) draw(img *image.RGBA) {
+	// set plots a point given along and across the guide, strip fills the
+	// part of the line from u1 to u2
+	set := func(u, v int) {
+		if g.orientation == GuideVertical {
+			u, v = v, u
+		}
+		img.SetRGBA(u, v, g.color)
+	}
+	// A line of this width is centered on pos
+	offset := g.pos - g.width/2
+	strip := func(u1, u2 int) {
+		if u1 >= u2 || g.width <= 0 {
+			return
+		}
+		r := image.Rect(u1, offset, u2, offset+g.width)
+		if g.orientation == GuideVertical {
+			r = image.Rect(offset, u1, offset+g.width, u2)
+		}
+		fillRect(img, r, g.color)
+	}
+
+	switch g.kind {
+	case GuideTypeFold:
+		for u := g.start; u < g.end; u += g.dash + g.gap {
+			strip(u, intMin(u+g.dash, g.end))
+		}
+	case GuideTypeCut:
+		strip(g.start, g.end)
+		drawScissors(set, g.start, g.pos, g.scissors)
+	}
+}
+
+// drawScissors draws a small pair of open scissors of the given size,
+// pointing along a guide, with the handles at u on the line at v.
+func drawScissors(set func(u, v int), u, v, size int) {
+	r := size / 6
+	// handles
+	for _, cv := range []int{v - size/4, v + size/4} {
+		cu := u + r
+		for a := 0; a < 64; a++ {
+			t := float64(a) * 2 * math.Pi / 64
+			set(cu+int(math.Round(float64(r)*math.Cos(t))), cv+int(math.Round(float64(r)*math.Sin(t))))
+		}
+	}
+	// blades cross on the line and open towards the cut
+	line := func(u1, v1, u2, v2 int) {
+		steps := intMax(abs(u2-u1), abs(v2-v1))
+		for s := 0; s <= steps; s++ {
+			set(u1+(u2-u1)*s/intMax(1, steps), v1+(v2-v1)*s/intMax(1, steps))
+		}
+	}
+	line(u+2*r, v-size/4, u+size, v+size/5)
+	line(u+2*r, v+size/4, u+size, v-size/5)
+}
@@ -0,0 +1,69 @@
+package zinelayout
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDrawGuides(t *testing.T) {
+	var zl ZineLayout
+	spec := `
+global: { ppi: 100 }
+page_setup:
+  grid_size: { rows: 2, columns: 2 }
+output_pages:
+  - id: front
+    guides:
+      - { type: cut, orientation: vertical, grid_line: 1, width: 1px }
+      - { type: fold, orientation: horizontal, position: 30px, from: 1, to: 2, width: 1px }
+    layout:
+      - { input_index: 1, position: { row: 0, column: 0 } }
+      - { input_index: 2, position: { row: 0, column: 1 } }
+      - { input_index: 3, position: { row: 1, column: 0 } }
+      - { input_index: 4, position: { row: 1, column: 1 } }
+`
+	if err := yaml.Unmarshal([]byte(spec), &zl); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inputs := make([]image.Image, 4)
+	for i := range inputs {
+		img := image.NewRGBA(image.Rect(0, 0, 50, 35))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		inputs[i] = img
+	}
+
+	page := zl.OutputPages[0]
+	plan, err := zl.PlanPage(page, inputs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := plan.Draw(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	black := color.RGBA{0, 0, 0, 255}
+	if out.RGBAAt(50, 40) != black {
+		t.Errorf("expected cut line at x=50")
+	}
+	if out.RGBAAt(51, 30) != black {
+		t.Errorf("expected fold line at y=30 inside its range")
+	}
+	if out.RGBAAt(20, 30) == black {
+		t.Errorf("fold line drawn outside its range")
+	}
+
+	two, three := 2, 3
+	page.Guides = []*Guide{{Type: GuideTypeFold, Orientation: GuideVertical, GridLine: &two, Position: "1in"}}
+	if _, err := zl.PlanPage(page, inputs); err == nil {
+		t.Errorf("expected error for guide with grid line and position")
+	}
+	page.Guides = []*Guide{{Type: GuideTypeFold, Orientation: GuideVertical, GridLine: &three}}
+	if _, err := zl.PlanPage(page, inputs); err == nil {
+		t.Errorf("expected error for grid line out of range")
+	}
+}
@@ -57,21 +57,6 @@ func GenerateTestImages(count, width, height int) ([]image.Image, error) {
 	return images, nil
 }
 
-func addLabel(img *image.RGBA, label string, textColor color.Color) {
-	point := fixed.Point26_6{
-		X: fixed.Int26_6(img.Bounds().Dx()/2) << 6,
-		Y: fixed.Int26_6(img.Bounds().Dy()/2) << 6,
-	}
-	d := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(textColor),
-		Face: basicfont.Face7x13,
-		Dot:  point,
-	}
-	d.Dot.X -= fixed.Int26_6(len(label) * 7 / 2 << 6)
-	d.DrawString(label)
-}
-
 func GenerateTestImagesBW(count, width, height int) ([]image.Image, error) {
 	var images []image.Image
 
@@ -91,24 +76,34 @@ func GenerateTestImagesBW(count, width, height int) ([]image.Image, error) {
 		draw.Draw(img, img.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
 
 		// Add page number to the image
-		addLabelBW(img, fmt.Sprintf("Page %d", i), color.Black)
+		addLabel(img, fmt.Sprintf("Page %d", i), color.Black)
 
 		images = append(images, img)
 	}
 	return images, nil
 }
 
-func addLabelBW(img *image.Gray, label string, textColor color.Color) {
-	point := fixed.Point26_6{
-		X: fixed.Int26_6(img.Bounds().Dx()/2) << 6,
-		Y: fixed.Int26_6(img.Bounds().Dy()/2) << 6,
+// addLabel draws label in the middle of img with the default font,
+// sized to the image so that it stays legible at print resolution.
+func addLabel(img draw.Image, label string, textColor color.Color) {
+	size := float64(intMax(13, img.Bounds().Dy()/20))
+	face, err := newFontFacePixels(DefaultFont, size)
+	if err != nil {
+		// the embedded font always parses, fall back to the bitmap font anyway
+		face = basicfont.Face7x13
 	}
+	defer func() { _ = face.Close() }()
+
+	metrics := face.Metrics()
+	width := font.MeasureString(face, label)
 	d := &font.Drawer{
 		Dst:  img,
 		Src:  image.NewUniform(textColor),
-		Face: basicfont.Face7x13,
-		Dot:  point,
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: fixed.I(img.Bounds().Min.X+img.Bounds().Dx()/2) - width/2,
+			Y: fixed.I(img.Bounds().Min.Y+img.Bounds().Dy()/2) + (metrics.Ascent-metrics.Descent)/2,
+		},
 	}
-	d.Dot.X -= fixed.Int26_6(len(label) * 7 / 2 << 6)
 	d.DrawString(label)
 }
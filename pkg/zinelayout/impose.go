@@ -0,0 +1,193 @@
+package zinelayout
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Binding is the edge a booklet is bound on
+type Binding string
+
+const (
+	// BindingLeft is the usual binding for left-to-right reading.
+	BindingLeft Binding = "left"
+	// BindingRight is used for right-to-left reading, pages run the other way.
+	BindingRight Binding = "right"
+)
+
+// ParseBinding converts a string to a Binding
+func ParseBinding(s string) (Binding, error) {
+	switch b := Binding(strings.ToLower(s)); b {
+	case BindingLeft, BindingRight:
+		return b, nil
+	default:
+		return "", fmt.Errorf("invalid binding: %s", s)
+	}
+}
+
+// ImposeOptions configures ImposeSaddleStitch
+type ImposeOptions struct {
+	// Pages is the number of content pages. The booklet is padded with blank
+	// pages at the end up to a full set of sheets.
+	Pages int
+	// PagesPerSide is the number of pages printed on each side of a sheet:
+	// 2, 4 or 8. Each row of the sheet holds one folded spread.
+	PagesPerSide int
+	Binding      Binding
+	PPI          float64
+	// SignaturePages splits the booklet into signatures of this many pages,
+	// each imposed the same way. It must be a multiple of the pages on one
+	// sheet. Zero imposes all pages as a single signature.
+	SignaturePages int
+	// NestedSignatures inserts every signature into the previous one
+	// instead of stacking them, see SignatureModeNested.
+	NestedSignatures bool
+	// Creep is the paper thickness used for creep compensation, as a unit
+	// expression. Empty disables it.
+	Creep string
+}
+
+// ImposedPages returns the number of pages of the booklet after padding
+// with blank pages, which is a multiple of the pages on one sheet, or of
+// the signature size when signatures are used.
+func (o ImposeOptions) ImposedPages() int {
+	unit := 2 * o.PagesPerSide
+	if o.SignaturePages > 0 {
+		unit = o.SignaturePages
+	}
+	return (o.Pages + unit - 1) / unit * unit
+}
+
+// Spread is the pair of pages printed next to each other on one side of a
+// folded sheet, as seen when looking at that side.
+type Spread struct {
+	Left  int
+	Right int
+}
+
+// SaddleStitchSpreads returns the front and back spreads of each folded
+// sheet of a saddle-stitched booklet of pages pages, outermost sheet first.
+// pages must be a multiple of 4.
+func SaddleStitchSpreads(pages int, binding Binding) ([]Spread, []Spread, error) {
+	if pages <= 0 || pages%4 != 0 {
+		return nil, nil, fmt.Errorf("saddle stitch needs a positive multiple of 4 pages, got %d", pages)
+	}
+	var front, back []Spread
+	for i := 0; i < pages/4; i++ {
+		f := Spread{Left: pages - 2*i, Right: 2*i + 1}
+		b := Spread{Left: 2*i + 2, Right: pages - 2*i - 1}
+		if binding == BindingRight {
+			f.Left, f.Right = f.Right, f.Left
+			b.Left, b.Right = b.Right, b.Left
+		}
+		front = append(front, f)
+		back = append(back, b)
+	}
+	return front, back, nil
+}
+
+// ImposeSaddleStitch generates a ZineLayout for a saddle-stitched booklet.
+//
+// Each output sheet has one row per folded spread and two columns. With more
+// than two pages per side the printed stack is cut along the rows ("cut and
+// stack"): the pile from the top row goes outside, the pile from the next
+// row inside it, and so on, before folding and stapling. Sheets are meant to
+// be printed duplex, flipping on the short edge for a single row.
+//
+// With opts.SignaturePages the output pages describe one signature and the
+// layout's Signatures repeat them for the rest of the booklet.
+//
+// Pages beyond opts.Pages are left blank. Cells are sized with the max
+// policy, so blank cells keep their place on the sheet.
+func ImposeSaddleStitch(opts ImposeOptions) (*ZineLayout, error) {
+	if opts.Pages <= 0 {
+		return nil, fmt.Errorf("page count must be positive, got %d", opts.Pages)
+	}
+	switch opts.PagesPerSide {
+	case 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("pages per side must be 2, 4 or 8, got %d", opts.PagesPerSide)
+	}
+	if opts.Binding == "" {
+		opts.Binding = BindingLeft
+	}
+	binding, err := ParseBinding(string(opts.Binding))
+	if err != nil {
+		return nil, err
+	}
+	ppi := opts.PPI
+	if ppi == 0 {
+		ppi = 300
+	}
+
+	pages := opts.ImposedPages()
+	blankAfter := opts.Pages
+	if opts.SignaturePages > 0 {
+		if opts.SignaturePages%(2*opts.PagesPerSide) != 0 {
+			return nil, fmt.Errorf("signature pages must be a multiple of %d, got %d", 2*opts.PagesPerSide, opts.SignaturePages)
+		}
+		// blank pages of the last signature are left out when rendering
+		pages = opts.SignaturePages
+		blankAfter = pages
+	}
+	front, back, err := SaddleStitchSpreads(pages, binding)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := opts.PagesPerSide / 2
+	sheets := len(front) / rows
+
+	zl := &ZineLayout{
+		Global: &Global{PPI: ppi},
+		PageSetup: &PageSetup{
+			CellSize: &CellSizing{Policy: CellSizePolicyMax},
+			Creep:    opts.Creep,
+		},
+	}
+	if opts.SignaturePages > 0 {
+		zl.Signatures = &Signatures{
+			Pages: opts.SignaturePages,
+			Count: (opts.Pages + opts.SignaturePages - 1) / opts.SignaturePages,
+		}
+		if opts.NestedSignatures {
+			zl.Signatures.Mode = SignatureModeNested
+		}
+	}
+	zl.PageSetup.GridSize.Rows = rows
+	zl.PageSetup.GridSize.Columns = 2
+
+	place := func(op *OutputPage, row int, depth int, s Spread) {
+		for column, page := range []int{s.Left, s.Right} {
+			if page > blankAfter {
+				continue // blank padding page
+			}
+			// the fold runs between the two columns
+			spine := EdgeRight
+			if column == 1 {
+				spine = EdgeLeft
+			}
+			op.Layout = append(op.Layout, &Layout{
+				InputIndex: page,
+				Position:   Position{Row: row, Column: column},
+				Sheet:      depth,
+				Spine:      spine,
+			})
+		}
+	}
+
+	for sheet := 0; sheet < sheets; sheet++ {
+		frontPage := &OutputPage{ID: fmt.Sprintf("sheet-%02d-front", sheet+1), Side: PageSideFront}
+		backPage := &OutputPage{ID: fmt.Sprintf("sheet-%02d-back", sheet+1), Side: PageSideBack}
+		for row := 0; row < rows; row++ {
+			// cut and stack: each row pile holds consecutive spreads
+			spread := row*sheets + sheet
+			// spreads are numbered from the outermost sheet of the booklet
+			place(frontPage, row, spread, front[spread])
+			place(backPage, row, spread, back[spread])
+		}
+		zl.OutputPages = append(zl.OutputPages, frontPage, backPage)
+	}
+
+	return zl, nil
+}
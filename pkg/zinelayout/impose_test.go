@@ -0,0 +1,86 @@
+package zinelayout
+
+import (
+	"testing"
+)
+
+func TestSaddleStitchSpreads(t *testing.T) {
+	front, back, err := SaddleStitchSpreads(8, BindingLeft)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedFront := []Spread{{8, 1}, {6, 3}}
+	expectedBack := []Spread{{2, 7}, {4, 5}}
+	for i := range expectedFront {
+		if front[i] != expectedFront[i] {
+			t.Errorf("front[%d] = %v, expected %v", i, front[i], expectedFront[i])
+		}
+		if back[i] != expectedBack[i] {
+			t.Errorf("back[%d] = %v, expected %v", i, back[i], expectedBack[i])
+		}
+	}
+
+	front, _, err = SaddleStitchSpreads(4, BindingRight)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if front[0] != (Spread{1, 4}) {
+		t.Errorf("right binding front = %v, expected {1 4}", front[0])
+	}
+
+	if _, _, err := SaddleStitchSpreads(6, BindingLeft); err == nil {
+		t.Errorf("expected error for page count that is not a multiple of 4")
+	}
+}
+
+func TestImposeSaddleStitch(t *testing.T) {
+	tests := []struct {
+		name         string
+		pages        int
+		pagesPerSide int
+		outputPages  int
+		rows         int
+	}{
+		{"8 pages, 2 per side", 8, 2, 4, 1},
+		{"6 pages padded", 6, 2, 4, 1},
+		{"16 pages, 4 per side", 16, 4, 4, 2},
+		{"20 pages, 8 per side", 20, 8, 4, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zl, err := ImposeSaddleStitch(ImposeOptions{Pages: tt.pages, PagesPerSide: tt.pagesPerSide})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(zl.OutputPages) != tt.outputPages {
+				t.Errorf("got %d output pages, expected %d", len(zl.OutputPages), tt.outputPages)
+			}
+			if zl.PageSetup.GridSize.Rows != tt.rows || zl.PageSetup.GridSize.Columns != 2 {
+				t.Errorf("got %dx%d grid, expected %dx2", zl.PageSetup.GridSize.Rows, zl.PageSetup.GridSize.Columns, tt.rows)
+			}
+
+			// Every content page is placed exactly once, blank pages never
+			seen := map[int]int{}
+			cells := map[string]bool{}
+			for _, op := range zl.OutputPages {
+				for _, l := range op.Layout {
+					seen[l.InputIndex]++
+					key := op.ID + ":" + string(rune('0'+l.Position.Row)) + string(rune('0'+l.Position.Column))
+					if cells[key] {
+						t.Errorf("two items in the same cell %s", key)
+					}
+					cells[key] = true
+				}
+			}
+			if len(seen) != tt.pages {
+				t.Errorf("placed %d distinct pages, expected %d", len(seen), tt.pages)
+			}
+			for page := 1; page <= tt.pages; page++ {
+				if seen[page] != 1 {
+					t.Errorf("page %d placed %d times", page, seen[page])
+				}
+			}
+		})
+	}
+}
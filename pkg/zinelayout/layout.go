@@ -1,10 +1,10 @@
 package zinelayout
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -14,35 +14,107 @@ type ZineLayout struct {
 	PageSetup   *PageSetup    `yaml:"page_setup"`
 	OutputPages []*OutputPage `yaml:"output_pages"`
 	Global      *Global       `yaml:"global"`
+	// Signatures repeats the output pages for consecutive runs of inputs.
+	Signatures *Signatures `yaml:"signatures,omitempty"`
+
+	// geometryPPI is the PPI PrepareGeometry last resolved the layout at.
+	geometryPPI float64
 }
 
 type Global struct {
-	Border *Border `yaml:"border"`
+	Border *Border `yaml:"border,omitempty"`
 	PPI    float64 `yaml:"ppi"`
+	// Printer holds printer specific corrections such as the duplex offset.
+	Printer *PrinterProfile `yaml:"printer,omitempty"`
 }
 
 type PageSetup struct {
 	GridSize struct {
 		Rows    int `yaml:"rows"`
 		Columns int `yaml:"columns"`
+		// ColumnWidths and RowHeights fix the size of each track: a unit
+		// expression, "auto" to fit the content, or a fraction such as
+		// "1fr" of the space the other tracks leave.
+		ColumnWidths []string `yaml:"column_widths,omitempty"`
+		RowHeights   []string `yaml:"row_heights,omitempty"`
 	} `yaml:"grid_size"`
-	Margin     *Margin `yaml:"margin"`
-	PageBorder *Border `yaml:"border"`
+	Margin     *Margin `yaml:"margin,omitempty"`
+	PageBorder *Border `yaml:"border,omitempty"`
+	// Paper fixes the size of the output sheet. Inputs are scaled to fit
+	// their grid cells when it is set.
+	Paper *Paper `yaml:"paper,omitempty"`
+	// Orientation turns the paper when Paper has no orientation of its
+	// own. It has no effect without Paper.
+	Orientation Orientation `yaml:"orientation,omitempty"`
+	// Scale is the default scale mode for all layout items. It defaults to
+	// fit when Paper is set and to none otherwise.
+	Scale ScaleMode `yaml:"scale,omitempty"`
+	// CellSize selects how grid cells are sized when Paper is not set.
+	CellSize *CellSizing `yaml:"cell_size,omitempty"`
+	// Align is the default alignment of inputs that don't fill their cell.
+	Align Alignment `yaml:"align,omitempty"`
+	// Bleed is the default bleed around the trim box of every layout item.
+	Bleed *Bleed `yaml:"bleed,omitempty"`
+	// CropMarks draws marks outside the corners of every trim box.
+	CropMarks *CropMarks `yaml:"crop_marks,omitempty"`
+	// Creep is the paper thickness used to shift items on inner sheets
+	// toward the spine, see Layout.Sheet.
+	Creep string `yaml:"creep,omitempty"`
+	// Folio draws page numbers on all layout items.
+	Folio *Folio `yaml:"folio,omitempty"`
+	// Crops are the default crops of the inputs, by input index.
+	Crops map[int]*Crop `yaml:"crops,omitempty"`
 }
 
 type OutputPage struct {
 	ID           string    `yaml:"id"`
-	Margin       *Margin   `yaml:"margin"`
+	Margin       *Margin   `yaml:"margin,omitempty"`
 	Layout       []*Layout `yaml:"layout"`
-	LayoutBorder *Border   `yaml:"border"`
+	LayoutBorder *Border   `yaml:"border,omitempty"`
+	// Scale overrides PageSetup.Scale for the items on this page.
+	Scale ScaleMode `yaml:"scale,omitempty"`
+	// Align overrides PageSetup.Align for the items on this page.
+	Align Alignment `yaml:"align,omitempty"`
+	// Guides are fold and cut lines drawn on top of the page.
+	Guides []*Guide `yaml:"guides,omitempty"`
+	// Offset moves the page content while keeping the sheet size.
+	Offset *Offset `yaml:"offset,omitempty"`
+	// Side marks back side pages, which get the printer duplex offset.
+	Side PageSide `yaml:"side,omitempty"`
+	// Text blocks drawn on top of the inputs.
+	Text []*TextElement `yaml:"text,omitempty"`
 }
 
 type Layout struct {
 	InputIndex        int      `yaml:"input_index"`
 	Position          Position `yaml:"position"`
 	Rotation          int      `yaml:"rotation"`
-	Margin            *Margin  `yaml:"margin"`
-	InnerLayoutBorder *Border  `yaml:"border"`
+	Margin            *Margin  `yaml:"margin,omitempty"`
+	InnerLayoutBorder *Border  `yaml:"border,omitempty"`
+	// Flip mirrors the input horizontally or vertically before rotation.
+	Flip Flip `yaml:"flip,omitempty"`
+	// Scale overrides the page scale mode for this item.
+	Scale ScaleMode `yaml:"scale,omitempty"`
+	// Align overrides the page alignment for this item.
+	Align Alignment `yaml:"align,omitempty"`
+	// Bleed overrides PageSetup.Bleed for this item.
+	Bleed *Bleed `yaml:"bleed,omitempty"`
+	// Sheet is the depth of the item's sheet in a saddle-stitched booklet,
+	// 0 for the outermost sheet. With PageSetup.Creep the input is shifted
+	// toward the Spine edge of its cell by Sheet paper thicknesses.
+	Sheet int  `yaml:"sheet,omitempty"`
+	Spine Edge `yaml:"spine,omitempty"`
+	// Folio overrides PageSetup.Folio for this item.
+	Folio *Folio `yaml:"folio,omitempty"`
+	// X and Y place the item at a distance from the top left corner of the
+	// sheet instead of in a grid cell. Width and Height default to the
+	// natural size of the item.
+	X      string `yaml:"x,omitempty"`
+	Y      string `yaml:"y,omitempty"`
+	Width  string `yaml:"width,omitempty"`
+	Height string `yaml:"height,omitempty"`
+	// Crop overrides the default crop of the input, see PageSetup.Crops.
+	Crop *Crop `yaml:"crop,omitempty"`
 }
 
 type Border struct {
@@ -52,141 +124,443 @@ type Border struct {
 }
 
 // Position represents the position of an input page on the output page
+//
+// RowSpan and ColumnSpan let an item cover several cells, for a wide cover
+// or a centerfold spread. They default to one cell.
 type Position struct {
-	Row    int `yaml:"row"`
-	Column int `yaml:"column"`
+	Row        int `yaml:"row"`
+	Column     int `yaml:"column"`
+	RowSpan    int `yaml:"row_span,omitempty"`
+	ColumnSpan int `yaml:"column_span,omitempty"`
 }
 
-func (zl *ZineLayout) CreateOutputImage(outputPage *OutputPage, inputImages []image.Image) (image.Image, error) {
-	if zl.Global.PPI == 0 {
-		return nil, fmt.Errorf("ppi is not set")
+// PagePlan is the geometry of an output page: the size of the sheet and
+// where every input, text, border, mark and guide goes on it. Draw renders
+// it as pixels and WriteSVG as vector elements. A plan is not modified
+// after PlanPage returns it, so it can be drawn and written concurrently.
+type PagePlan struct {
+	page  *OutputPage
+	size  image.Point
+	ppi   float64
+	lines gridLines
+	items []*itemPlan
+	// texts are the folios and text elements, in drawing order.
+	texts []*placedText
+	// borders are the layout and inner layout borders, in drawing order.
+	borders    []borderPlan
+	marks      []image.Rectangle
+	markColor  color.RGBA
+	pageBorder *borderPlan
+	guides     []guideLine
+	// offset shifts everything but the global border for printer
+	// registration.
+	offset       image.Point
+	globalBorder *borderPlan
+}
+
+// itemPlan is where the input of a layout item goes on the sheet.
+type itemPlan struct {
+	layout *Layout
+	// input is the whole input image, crop the part of it that is used and
+	// source that part.
+	input    image.Image
+	crop     image.Rectangle
+	source   image.Image
+	rotation int
+	flip     Flip
+	cell     image.Rectangle
+	// trim is the cell without margins, the artwork extends past it by the
+	// bleed.
+	trim    image.Rectangle
+	artwork image.Rectangle
+	// place is the box of the flipped, rotated and scaled source, empty when
+	// nothing is drawn. A mirrored bleed extends it by bleed on all sides.
+	place  image.Rectangle
+	bleed  int
+	mirror bool
+	// redraw is set when the bleed of another item spills into the trim
+	// box, which is then drawn a second time.
+	redraw bool
+}
+
+// borderPlan is a border drawn around a rectangle.
+type borderPlan struct {
+	rect  image.Rectangle
+	color color.RGBA
+	kind  BorderType
+}
+
+func newBorderPlan(rect image.Rectangle, b *Border) borderPlan {
+	c := b.Color.RGBA
+	if c == (color.RGBA{}) {
+		c = color.RGBA{0, 0, 0, 255}
 	}
-	err := zl.ComputeAllMargins()
+	return borderPlan{rect: rect, color: c, kind: b.Type}
+}
+
+// CreateOutputImage lays out an output page and draws it. Drawing stops
+// with the error of ctx when ctx is cancelled.
+func (zl *ZineLayout) CreateOutputImage(ctx context.Context, outputPage *OutputPage, inputImages []image.Image) (image.Image, error) {
+	plan, err := zl.PlanPage(outputPage, inputImages)
 	if err != nil {
-		return nil, fmt.Errorf("error computing all margins: %w", err)
+		return nil, err
 	}
+	return plan.Draw(ctx)
+}
 
-	fmt.Println("Creating output image")
+// PrepareGeometry resolves the unit expressions of the layout, its margins
+// and paper size, to pixels at the global PPI. It is the only step of a
+// render that writes to the layout. Afterwards ExpandOutputPages,
+// PlanPage, CreateOutputImage and CreateOutputSVG only read it, so output
+// pages can be rendered concurrently. Calling it again for the same PPI
+// does nothing.
+func (zl *ZineLayout) PrepareGeometry() error {
+	if zl.Global.PPI == 0 {
+		return fmt.Errorf("ppi is not set")
+	}
+	if zl.geometryPPI == zl.Global.PPI {
+		return nil
+	}
+	if err := zl.ComputeAllMargins(); err != nil {
+		return fmt.Errorf("error computing all margins: %w", err)
+	}
+	if paper := zl.PageSetup.Paper; paper != nil {
+		if err := paper.computePixelValues(zl.Global.PPI, zl.PageSetup.Orientation); err != nil {
+			return fmt.Errorf("error computing paper size: %w", err)
+		}
+	}
+	zl.geometryPPI = zl.Global.PPI
+	return nil
+}
+
+// PlanPage lays out an output page without drawing it. The layout geometry
+// is prepared on first use; call PrepareGeometry before planning pages from
+// several goroutines.
+func (zl *ZineLayout) PlanPage(outputPage *OutputPage, inputImages []image.Image) (*PagePlan, error) {
+	if err := zl.PrepareGeometry(); err != nil {
+		return nil, err
+	}
+	paper := zl.PageSetup.Paper
+
+	log.Debug().Msg("Creating output image")
 	for _, inputImage := range inputImages {
-		fmt.Printf("Input image size: %v\n", inputImage.Bounds().Size())
+		log.Debug().Msgf("Input image size: %v", inputImage.Bounds().Size())
+	}
+	// Cells are sized from the inputs without what their default crops
+	// remove.
+	croppedInputs, err := zl.cropDefaults(inputImages)
+	if err != nil {
+		return nil, fmt.Errorf("error cropping inputs: %w", err)
+	}
+	uniformSize, uniform, err := zl.PageSetup.CellSize.uniformCellSize(croppedInputs, zl.Global.PPI)
+	if err != nil {
+		return nil, fmt.Errorf("error computing cell size: %w", err)
 	}
-	inputSize := inputImages[0].Bounds().Size()
 
-	type CellSize struct {
-		Margin *Margin
-		Width  int
-		Height int
-		X      int
-		Y      int
+	// Margins are expressed in the frame of the input page, so they turn
+	// together with the image.
+	margins := make([]*Margin, len(outputPage.Layout))
+	scaleModes := make([]ScaleMode, len(outputPage.Layout))
+	alignments := make([]Alignment, len(outputPage.Layout))
+	bleeds := make([]int, len(outputPage.Layout))
+	mirrors := make([]bool, len(outputPage.Layout))
+	shifts := make([]image.Point, len(outputPage.Layout))
+	flips := make([]Flip, len(outputPage.Layout))
+	sources := make([]image.Image, len(outputPage.Layout))
+	for i, layout := range outputPage.Layout {
+		rotation, err := normalizeRotation(layout.Rotation)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rotation %d for input index %d: %w", layout.Rotation, layout.InputIndex, err)
+		}
+		flips[i], err = ParseFlip(string(layout.Flip))
+		if err != nil {
+			return nil, fmt.Errorf("input index %d: %w", layout.InputIndex, err)
+		}
+		if err := layout.checkSize(); err != nil {
+			return nil, fmt.Errorf("input index %d: %w", layout.InputIndex, err)
+		}
+		if !layout.isAbsolute() {
+			if err := checkPosition(layout.Position, zl.PageSetup.GridSize.Rows, zl.PageSetup.GridSize.Columns); err != nil {
+				return nil, fmt.Errorf("input index %d: %w", layout.InputIndex, err)
+			}
+		}
+		if layout.InputIndex < 1 || layout.InputIndex > len(inputImages) {
+			return nil, fmt.Errorf("input index %d out of range (have %d input images)", layout.InputIndex, len(inputImages))
+		}
+		sources[i], err = zl.cropInput(layout, inputImages)
+		if err != nil {
+			return nil, err
+		}
+		margins[i] = rotateMargin(flipMargin(layout.Margin, flips[i]), rotation)
+		scaleModes[i], err = zl.scaleModeFor(outputPage, layout)
+		if err != nil {
+			return nil, err
+		}
+		alignments[i], err = zl.alignmentFor(outputPage, layout)
+		if err != nil {
+			return nil, err
+		}
+		bleeds[i], mirrors[i], err = zl.bleedFor(layout)
+		if err != nil {
+			return nil, err
+		}
+		shifts[i], err = zl.creepShift(layout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := checkOverlaps(outputPage.Layout, zl.PageSetup.GridSize.Rows, zl.PageSetup.GridSize.Columns); err != nil {
+		return nil, fmt.Errorf("output page %s: %w", outputPage.ID, err)
 	}
 
-	// Create a 2D array to store CellSize for each cell
-	cells := make([][]CellSize, zl.PageSetup.GridSize.Rows)
-	for row := range cells {
-		cells[row] = make([]CellSize, zl.PageSetup.GridSize.Columns)
-		for column := range cells[row] {
-			cells[row][column] = CellSize{Margin: &Margin{}}
+	// naturalSize is the size of an item's cell when the cell is sized
+	// from its content: the input, rotated and without its bleed, plus
+	// margins.
+	naturalSize := func(i int) (image.Point, error) {
+		layout := outputPage.Layout[i]
+		rotation, _ := normalizeRotation(layout.Rotation)
+		size := sources[i].Bounds().Size()
+		if uniform {
+			size = uniformSize
+		}
+		size = rotatedSize(size, rotation)
+		if !mirrors[i] {
+			// The input includes its bleed, the trim box is inside it
+			size = size.Sub(image.Pt(2*bleeds[i], 2*bleeds[i]))
+			if size.X <= 0 || size.Y <= 0 {
+				return image.Point{}, fmt.Errorf("bleed is larger than input index %d", layout.InputIndex)
+			}
 		}
+		margin := margins[i]
+		return size.Add(image.Pt(
+			margin.Left.Pixels+margin.Right.Pixels,
+			margin.Top.Pixels+margin.Bottom.Pixels,
+		)), nil
 	}
 
-	// Calculate cell sizes and update cells
-	for _, layout := range outputPage.Layout {
-		row, col := int(layout.Position.Row), int(layout.Position.Column)
-		cells[row][col].Margin = layout.Margin
-		cells[row][col].Width = inputSize.X + layout.Margin.Left.Pixels + layout.Margin.Right.Pixels
-		cells[row][col].Height = inputSize.Y + layout.Margin.Top.Pixels + layout.Margin.Bottom.Pixels
+	// Auto and fraction tracks are sized from the items placed in them.
+	// Items spanning several cells grow the tracks they cover.
+	var columnRequests, rowRequests []trackRequest
+	for i, layout := range outputPage.Layout {
+		if layout.isAbsolute() {
+			continue
+		}
+		size, err := naturalSize(i)
+		if err != nil {
+			return nil, err
+		}
+		p := layout.Position
+		columnRequests = append(columnRequests, trackRequest{start: p.Column, span: p.columnSpan(), size: size.X})
+		rowRequests = append(rowRequests, trackRequest{start: p.Row, span: p.rowSpan(), size: size.Y})
 	}
 
-	totalHeight := 0
-	totalWidth := 0
-	// Calculate output image size and cell positions
-	width, height := 0, 0
-	for row := range cells {
-		maxCellHeight := 0
-		for column := range cells[row] {
-			cells[row][column].X = width
-			cells[row][column].Y = height
-			width += cells[row][column].Width
-			maxCellHeight = intMax(maxCellHeight, cells[row][column].Height)
+	// Without explicit track sizes a fixed sheet is split evenly into
+	// cells, otherwise each column is as wide as its widest item and each
+	// row as high as its highest item.
+	fallback := trackSpec{kind: trackAuto}
+	width, height := -1, -1
+	minWidth, minHeight := 0, 0
+	if paper != nil {
+		fallback = trackSpec{kind: trackFraction, fr: 1}
+		width = paper.WidthPixels - zl.PageSetup.Margin.Left.Pixels - zl.PageSetup.Margin.Right.Pixels - outputPage.Margin.Left.Pixels - outputPage.Margin.Right.Pixels
+		height = paper.HeightPixels - zl.PageSetup.Margin.Top.Pixels - zl.PageSetup.Margin.Bottom.Pixels - outputPage.Margin.Top.Pixels - outputPage.Margin.Bottom.Pixels
+		if width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("margins leave no room on paper %s", paper.String())
 		}
-		height += maxCellHeight
-		totalWidth = intMax(totalWidth, width)
-		totalHeight += maxCellHeight
-		width = 0 // Reset width for the next row
+	} else if uniform {
+		// Empty cells keep their place in a uniform grid
+		minWidth, minHeight = uniformSize.X, uniformSize.Y
+	}
+	gridSize := zl.PageSetup.GridSize
+	columnSpecs, err := parseTrackSpecs(gridSize.ColumnWidths, gridSize.Columns, fallback, zl.Global.PPI)
+	if err != nil {
+		return nil, fmt.Errorf("column widths: %w", err)
+	}
+	rowSpecs, err := parseTrackSpecs(gridSize.RowHeights, gridSize.Rows, fallback, zl.Global.PPI)
+	if err != nil {
+		return nil, fmt.Errorf("row heights: %w", err)
+	}
+	columnWidths, err := sizeTracks(columnSpecs, minWidth, columnRequests, width)
+	if err != nil {
+		return nil, fmt.Errorf("output page %s: columns: %w", outputPage.ID, err)
+	}
+	rowHeights, err := sizeTracks(rowSpecs, minHeight, rowRequests, height)
+	if err != nil {
+		return nil, fmt.Errorf("output page %s: rows: %w", outputPage.ID, err)
 	}
 
-	// Final output image size
-	width = totalWidth
-	height = totalHeight
+	// Cells are placed inside the page setup and output page margins, which
+	// leaves room for bleed and crop marks around the outer items.
+	originX := zl.PageSetup.Margin.Left.Pixels + outputPage.Margin.Left.Pixels
+	originY := zl.PageSetup.Margin.Top.Pixels + outputPage.Margin.Top.Pixels
+	lines := newGridLines(image.Pt(originX, originY), columnWidths, rowHeights)
+	if paper == nil {
+		width = lines.columns[len(lines.columns)-1] - originX
+		height = lines.rows[len(lines.rows)-1] - originY
+	}
 
-	fmt.Printf("Total width: %d, Total height: %d\n", width, height)
+	log.Debug().Msgf("Total width: %d, Total height: %d", width, height)
 
-	// Create the output image without global margins
-	outputImage := image.NewRGBA(image.Rect(0, 0, width, height))
+	finalWidth := width + zl.PageSetup.Margin.Left.Pixels + zl.PageSetup.Margin.Right.Pixels + outputPage.Margin.Left.Pixels + outputPage.Margin.Right.Pixels
+	finalHeight := height + zl.PageSetup.Margin.Top.Pixels + zl.PageSetup.Margin.Bottom.Pixels + outputPage.Margin.Top.Pixels + outputPage.Margin.Bottom.Pixels
 
-	// Fill the output image with white color
-	draw.Draw(outputImage, outputImage.Bounds(), image.White, image.Point{}, draw.Src)
+	// Grid items fill their cells, absolute items their own box. Without
+	// a paper size the sheet grows to fit the absolute items.
+	cells := make([]image.Rectangle, len(outputPage.Layout))
+	for i, layout := range outputPage.Layout {
+		if !layout.isAbsolute() {
+			cells[i] = lines.rect(layout.Position)
+			continue
+		}
+		size, err := naturalSize(i)
+		if err != nil {
+			return nil, err
+		}
+		cells[i], err = layout.absoluteRect(size, zl.Global.PPI)
+		if err != nil {
+			return nil, fmt.Errorf("input index %d: %w", layout.InputIndex, err)
+		}
+		if paper == nil {
+			finalWidth = intMax(finalWidth, cells[i].Max.X+zl.PageSetup.Margin.Right.Pixels+outputPage.Margin.Right.Pixels)
+			finalHeight = intMax(finalHeight, cells[i].Max.Y+zl.PageSetup.Margin.Bottom.Pixels+outputPage.Margin.Bottom.Pixels)
+		}
+	}
+	if err := checkAbsoluteItems(outputPage.Layout, cells, image.Rect(0, 0, finalWidth, finalHeight)); err != nil {
+		return nil, fmt.Errorf("output page %s: %w", outputPage.ID, err)
+	}
 
-	// Use the specified border color or default to black if not set
-	globalBorderColor := color.RGBA{0, 0, 0, 255}
-	if zl.Global.Border != nil && zl.Global.Border.Color.RGBA != (color.RGBA{}) {
-		globalBorderColor = zl.Global.Border.Color.RGBA
+	plan := &PagePlan{
+		page:  outputPage,
+		size:  image.Pt(finalWidth, finalHeight),
+		ppi:   zl.Global.PPI,
+		lines: lines,
+		items: make([]*itemPlan, len(outputPage.Layout)),
 	}
 
-	for _, layout := range outputPage.Layout {
-		if layout.Rotation != 0 && layout.Rotation != 180 {
-			return nil, fmt.Errorf("invalid rotation %d for input index %d", layout.Rotation, layout.InputIndex)
+	// The trim box of an item is its cell without margins, the artwork
+	// extends past it by the bleed.
+	for i, layout := range outputPage.Layout {
+		rotation, _ := normalizeRotation(layout.Rotation)
+		cell := cells[i]
+		innerRect := image.Rect(
+			cell.Min.X+margins[i].Left.Pixels,
+			cell.Min.Y+margins[i].Top.Pixels,
+			cell.Max.X-margins[i].Right.Pixels,
+			cell.Max.Y-margins[i].Bottom.Pixels,
+		)
+
+		if innerRect.Dx() <= 0 || innerRect.Dy() <= 0 {
+			return nil, fmt.Errorf("margins leave no room for input index %d", layout.InputIndex)
+		}
+		bleed := bleeds[i]
+		bleedRect := innerRect.Inset(-bleed)
+		input := inputImages[layout.InputIndex-1]
+		crop, err := zl.cropRect(layout, input)
+		if err != nil {
+			return nil, err
+		}
+		item := &itemPlan{
+			layout:   layout,
+			input:    input,
+			crop:     crop,
+			source:   sources[i],
+			rotation: rotation,
+			flip:     flips[i],
+			cell:     cell,
+			trim:     innerRect,
+			artwork:  bleedRect,
+			bleed:    bleed,
+			mirror:   mirrors[i],
+		}
+		plan.items[i] = item
+
+		size := rotatedSize(sources[i].Bounds().Size(), rotation)
+		if mirrors[i] {
+			// The input covers the trim box, mirror its edges into the bleed
+			drawSize := scaledSize(scaleModes[i], size, innerRect.Size())
+			if drawSize.X <= 0 || drawSize.Y <= 0 {
+				continue
+			}
+			item.place = alignIn(innerRect, drawSize, alignments[i]).Add(shifts[i])
+			continue
 		}
 
-		inputImage := inputImages[layout.InputIndex-1]
-		destPoint := image.Point{
-			X: cells[layout.Position.Row][layout.Position.Column].X + layout.Margin.Left.Pixels,
-			Y: cells[layout.Position.Row][layout.Position.Column].Y + layout.Margin.Top.Pixels,
+		// The input covers the trim box and its bleed
+		drawSize := scaledSize(scaleModes[i], size, bleedRect.Size())
+		if drawSize.X <= 0 || drawSize.Y <= 0 {
+			continue
 		}
+		// Creep moves the input inside its trim box
+		item.place = alignIn(bleedRect, drawSize, alignments[i]).Add(shifts[i])
+	}
+	for i, item := range plan.items {
+		for j, other := range plan.items {
+			if j != i && other.bleed > 0 && other.artwork.Overlaps(item.trim) {
+				item.redraw = true
+				break
+			}
+		}
+	}
 
-		// Handle rotation
-		rotatedImage := rotateImage(inputImage, layout.Rotation)
-		rotatedSize := rotatedImage.Bounds().Size()
+	// Page numbers
+	for i, item := range plan.items {
+		folio := zl.folioFor(item.layout)
+		if folio == nil {
+			continue
+		}
+		// Folios move with the creep of their input
+		t, err := folio.place(item.trim.Add(shifts[i]), item.layout.InputIndex, len(inputImages), item.rotation, item.flip, zl.Global.PPI)
+		if err != nil {
+			return nil, fmt.Errorf("folio of input index %d: %w", item.layout.InputIndex, err)
+		}
+		if t != nil {
+			plan.texts = append(plan.texts, t)
+		}
+	}
 
-		// Draw the rotated input image onto the output image
-		draw.Draw(outputImage, image.Rect(destPoint.X, destPoint.Y, destPoint.X+rotatedSize.X, destPoint.Y+rotatedSize.Y), rotatedImage, image.Point{}, draw.Over)
+	// Text elements
+	cellRect := func(p Position) (image.Rectangle, error) {
+		if err := checkPosition(p, zl.PageSetup.GridSize.Rows, zl.PageSetup.GridSize.Columns); err != nil {
+			return image.Rectangle{}, err
+		}
+		return lines.rect(p), nil
 	}
+	texts, err := layoutTextElements(outputPage.Text, cellRect, zl.Global.PPI)
+	if err != nil {
+		return nil, fmt.Errorf("output page %s: %w", outputPage.ID, err)
+	}
+	plan.texts = append(plan.texts, texts...)
 
-	// Draw layout borders and inner layout borders
-	for _, layout := range outputPage.Layout {
-		cell := cells[layout.Position.Row][layout.Position.Column]
+	// Layout borders and inner layout borders
+	for _, item := range plan.items {
 		if outputPage.LayoutBorder != nil && outputPage.LayoutBorder.Enabled {
-			drawBorder(outputImage, image.Rect(cell.X, cell.Y, cell.X+cell.Width, cell.Y+cell.Height), outputPage.LayoutBorder.Color.RGBA, outputPage.LayoutBorder.Type)
+			plan.borders = append(plan.borders, newBorderPlan(item.cell, outputPage.LayoutBorder))
 		}
-		if layout.InnerLayoutBorder != nil && layout.InnerLayoutBorder.Enabled {
-			innerRect := image.Rect(
-				cell.X+layout.Margin.Left.Pixels,
-				cell.Y+layout.Margin.Top.Pixels,
-				cell.X+cell.Width-layout.Margin.Right.Pixels,
-				cell.Y+cell.Height-layout.Margin.Bottom.Pixels,
-			)
-			drawBorder(outputImage, innerRect, layout.InnerLayoutBorder.Color.RGBA, layout.InnerLayoutBorder.Type)
+		if b := item.layout.InnerLayoutBorder; b != nil && b.Enabled {
+			plan.borders = append(plan.borders, newBorderPlan(item.trim, b))
 		}
 	}
 
-	// Add global margins to the final image
-	finalWidth := width + zl.PageSetup.Margin.Left.Pixels + zl.PageSetup.Margin.Right.Pixels + outputPage.Margin.Left.Pixels + outputPage.Margin.Right.Pixels
-	finalHeight := height + zl.PageSetup.Margin.Top.Pixels + zl.PageSetup.Margin.Bottom.Pixels + outputPage.Margin.Top.Pixels + outputPage.Margin.Bottom.Pixels
-	finalImage := image.NewRGBA(image.Rect(0, 0, finalWidth, finalHeight))
-
-	// Fill the final image with white color
-	draw.Draw(finalImage, finalImage.Bounds(), image.White, image.Point{}, draw.Src)
-
-	// Draw the output image onto the final image with margins
-	outputRect := image.Rect(
-		zl.PageSetup.Margin.Left.Pixels+outputPage.Margin.Left.Pixels,
-		zl.PageSetup.Margin.Top.Pixels+outputPage.Margin.Top.Pixels,
-		finalWidth-zl.PageSetup.Margin.Right.Pixels-outputPage.Margin.Right.Pixels,
-		finalHeight-zl.PageSetup.Margin.Bottom.Pixels-outputPage.Margin.Bottom.Pixels,
-	)
-	draw.Draw(finalImage, outputRect, outputImage, image.Point{0, 0}, draw.Over)
+	if cm := zl.PageSetup.CropMarks; cm != nil && cm.Enabled {
+		length, offset, err := cm.pixelValues(zl.Global.PPI)
+		if err != nil {
+			return nil, err
+		}
+		plan.markColor = color.RGBA{0, 0, 0, 255}
+		if cm.Color.RGBA != (color.RGBA{}) {
+			plan.markColor = cm.Color.RGBA
+		}
+		artwork := make([]image.Rectangle, len(plan.items))
+		for i, item := range plan.items {
+			artwork[i] = item.artwork
+		}
+		for _, item := range plan.items {
+			markOffset := intMax(offset, item.bleed)
+			plan.marks = append(plan.marks, cropMarkRects([]image.Rectangle{item.trim}, artwork, markOffset, length)...)
+		}
+	}
 
-	// Draw page border
+	// Page border
 	if zl.PageSetup.PageBorder != nil && zl.PageSetup.PageBorder.Enabled {
 		borderRect := image.Rect(
 			zl.PageSetup.Margin.Left.Pixels,
@@ -194,33 +568,158 @@ func (zl *ZineLayout) CreateOutputImage(outputPage *OutputPage, inputImages []im
 			finalWidth-zl.PageSetup.Margin.Right.Pixels,
 			finalHeight-zl.PageSetup.Margin.Bottom.Pixels,
 		)
-		fmt.Printf("Output page border: Top: %d, Bottom: %d, Left: %d, Right: %d, Color: %v, Type: %v\n",
+		log.Debug().Msgf("Output page border: Top: %d, Bottom: %d, Left: %d, Right: %d, Color: %v, Type: %v",
 			borderRect.Min.Y, borderRect.Max.Y, borderRect.Min.X, borderRect.Max.X, zl.PageSetup.PageBorder.Color.RGBA, zl.PageSetup.PageBorder.Type)
-		drawBorder(finalImage, borderRect, zl.PageSetup.PageBorder.Color.RGBA, zl.PageSetup.PageBorder.Type)
+		b := newBorderPlan(borderRect, zl.PageSetup.PageBorder)
+		plan.pageBorder = &b
+	}
+
+	plan.guides, err = resolveGuides(outputPage.Guides, lines, image.Rect(0, 0, finalWidth, finalHeight), zl.Global.PPI)
+	if err != nil {
+		return nil, fmt.Errorf("output page %s: %w", outputPage.ID, err)
+	}
+
+	// Shift the content for printer registration
+	plan.offset, err = zl.pageOffset(outputPage)
+	if err != nil {
+		return nil, err
 	}
 
-	// Draw global border
 	if zl.Global.Border != nil && zl.Global.Border.Enabled {
-		drawBorder(finalImage, finalImage.Bounds(), globalBorderColor, zl.Global.Border.Type)
+		b := newBorderPlan(image.Rect(0, 0, finalWidth, finalHeight), zl.Global.Border)
+		plan.globalBorder = &b
 	}
 
-	fmt.Printf("Global Margins - Top: %s, Bottom: %s, Left: %s, Right: %s\n",
+	log.Debug().Msgf("Global Margins - Top: %s, Bottom: %s, Left: %s, Right: %s",
 		zl.PageSetup.Margin.Top.String(),
 		zl.PageSetup.Margin.Bottom.String(),
 		zl.PageSetup.Margin.Left.String(),
 		zl.PageSetup.Margin.Right.String(),
 	)
-	fmt.Printf("Output Page Margins - Top: %s, Bottom: %s, Left: %s, Right: %s\n",
+	log.Debug().Msgf("Output Page Margins - Top: %s, Bottom: %s, Left: %s, Right: %s",
 		outputPage.Margin.Top.String(),
 		outputPage.Margin.Bottom.String(),
 		outputPage.Margin.Left.String(),
 		outputPage.Margin.Right.String(),
 	)
 
+	return plan, nil
+}
+
+// Draw renders the page as an image. It checks ctx between the inputs and
+// between batches of rows while compositing them, and returns the error of
+// ctx once it is cancelled.
+func (p *PagePlan) Draw(ctx context.Context) (*image.RGBA, error) {
+	finalImage := image.NewRGBA(image.Rect(0, 0, p.size.X, p.size.Y))
+
+	// Fill the final image with white color
+	fillRect(finalImage, finalImage.Bounds(), color.RGBA{255, 255, 255, 255})
+
+	// Handle flips and rotation, then scale the inputs to their boxes
+	drawn := make([]image.Image, len(p.items))
+	dests := make([]image.Rectangle, len(p.items))
+	for i, item := range p.items {
+		if item.place.Empty() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rotatedImage := rotateImage(flipImage(item.source, item.flip), item.rotation)
+		drawn[i] = scaleImage(rotatedImage, item.place.Dx(), item.place.Dy())
+		dests[i] = item.place
+		if item.mirror {
+			drawn[i] = mirrorExtend(drawn[i], item.bleed)
+			dests[i] = item.place.Inset(-item.bleed)
+		}
+	}
+
+	// Draw the inputs cropped to their bleed, then redraw the trim boxes
+	// that another item's bleed spilled into.
+	for i, item := range p.items {
+		if drawn[i] == nil {
+			continue
+		}
+		if err := drawClipped(ctx, finalImage, dests[i], drawn[i], item.artwork); err != nil {
+			return nil, err
+		}
+	}
+	for i, item := range p.items {
+		if drawn[i] == nil || !item.redraw {
+			continue
+		}
+		if err := drawClipped(ctx, finalImage, dests[i], drawn[i], item.trim); err != nil {
+			return nil, err
+		}
+	}
+
+	// Draw page numbers and text elements
+	for _, t := range p.texts {
+		t.draw(finalImage)
+	}
+
+	// Draw layout borders and inner layout borders
+	for _, b := range p.borders {
+		drawBorder(finalImage, b.rect, b.color, b.kind)
+	}
+
+	for _, r := range p.marks {
+		fillRect(finalImage, r, p.markColor)
+	}
+
+	// Draw page border
+	if p.pageBorder != nil {
+		drawBorder(finalImage, p.pageBorder.rect, p.pageBorder.color, p.pageBorder.kind)
+	}
+
+	for _, g := range p.guides {
+		g.draw(finalImage)
+	}
+
+	// Shift the content for printer registration
+	finalImage = shiftImage(finalImage, p.offset)
+
+	// Draw global border
+	if p.globalBorder != nil {
+		drawBorder(finalImage, finalImage.Bounds(), p.globalBorder.color, p.globalBorder.kind)
+	}
 	return finalImage, nil
 }
 
-// New function to handle image rotation
+// scaleModeFor resolves the scale mode of a layout item, falling back to the
+// output page and page setup defaults.
+func (zl *ZineLayout) scaleModeFor(outputPage *OutputPage, layout *Layout) (ScaleMode, error) {
+	for _, mode := range []ScaleMode{layout.Scale, outputPage.Scale, zl.PageSetup.Scale} {
+		if mode != "" {
+			m, err := ParseScaleMode(string(mode))
+			if err != nil {
+				return "", fmt.Errorf("input index %d: %w", layout.InputIndex, err)
+			}
+			return m, nil
+		}
+	}
+	if zl.PageSetup.Paper != nil {
+		return ScaleModeFit, nil
+	}
+	return ScaleModeNone, nil
+}
+
+// alignmentFor resolves the alignment of a layout item, falling back to the
+// output page and page setup defaults.
+func (zl *ZineLayout) alignmentFor(outputPage *OutputPage, layout *Layout) (Alignment, error) {
+	for _, align := range []Alignment{layout.Align, outputPage.Align, zl.PageSetup.Align} {
+		if align != "" {
+			a, err := ParseAlignment(string(align))
+			if err != nil {
+				return "", fmt.Errorf("input index %d: %w", layout.InputIndex, err)
+			}
+			return a, nil
+		}
+	}
+	return AlignCenter, nil
+}
+
+// AllImagesSameSize reports whether all images have the same pixel size
 func AllImagesSameSize(images []image.Image) bool {
 	if len(images) == 0 {
 		return true
@@ -276,7 +775,7 @@ func (zl *ZineLayout) ComputeAllMargins() error {
 				zl.OutputPages[i].Layout[j].Margin = &Margin{}
 			}
 			margins = append(margins, zl.OutputPages[i].Layout[j].Margin)
-			fmt.Printf("Margin: %+v\n", zl.OutputPages[i].Layout[j].Margin)
+			log.Debug().Msgf("Margin: %+v", zl.OutputPages[i].Layout[j].Margin)
 		}
 	}
 
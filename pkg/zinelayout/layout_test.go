@@ -0,0 +1,208 @@
+package zinelayout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+type exampleSpec struct {
+	name string
+	zl   *ZineLayout
+}
+
+// loadExampleSpecs reads the layouts of the bundled example specs.
+func loadExampleSpecs(tb testing.TB) []exampleSpec {
+	return loadSpecs(tb, "../../examples/tests/*.yaml", "../../examples/layouts/*.yaml")
+}
+
+// loadSpecs reads the layouts of the spec files matching patterns, named
+// after their files. Multi-document specs get a -N suffix per document.
+func loadSpecs(tb testing.TB, patterns ...string) []exampleSpec {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		tb.Fatalf("no specs found for %v", patterns)
+	}
+
+	var specs []exampleSpec
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".yaml")
+		decoder := yaml.NewDecoder(f)
+		for i := 1; ; i++ {
+			zl := &ZineLayout{}
+			err := decoder.Decode(zl)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				tb.Fatalf("%s: %v", file, err)
+			}
+			if zl.Global == nil {
+				zl.Global = &Global{}
+			}
+			if zl.Global.PPI == 0 {
+				zl.Global.PPI = 300
+			}
+			key := name
+			if i > 1 {
+				key = name + "-" + strconv.Itoa(i)
+			}
+			specs = append(specs, exampleSpec{name: key, zl: zl})
+		}
+		_ = f.Close()
+	}
+	return specs
+}
+
+// TestPlanSpecs lays out every page of the example specs and of the presets
+// seeded by serve, so that a spec change that breaks them, like an overlap,
+// is caught.
+func TestPlanSpecs(t *testing.T) {
+	specs := loadExampleSpecs(t)
+	for _, preset := range loadSpecs(t, "../../data/presets/*.yaml") {
+		specs = append(specs, exampleSpec{name: "preset " + preset.name, zl: preset.zl})
+	}
+	for _, spec := range specs {
+		t.Run(spec.name, func(t *testing.T) {
+			zl := spec.zl
+			inputs, err := GenerateTestImages(zl.InputCount(), 85, 110)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := zl.PrepareGeometry(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pages, err := zl.ExpandOutputPages(len(inputs))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, page := range pages {
+				if _, err := zl.PlanPage(page, inputs); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// TestConcurrentPages renders the pages of an imposed zine with signatures
+// concurrently and checks that they match the pages rendered one after
+// another. The signature copies share their margins with the spec pages;
+// run with -race to catch writes to the shared layout.
+func TestConcurrentPages(t *testing.T) {
+	zl, err := ImposeSaddleStitch(ImposeOptions{Pages: 16, PagesPerSide: 2, SignaturePages: 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zl.Global.PPI = 20
+	inputs, err := GenerateTestImages(zl.InputCount(), 85, 110)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := zl.PrepareGeometry(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pages, err := zl.ExpandOutputPages(len(inputs))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	serial := make([]image.Image, len(pages))
+	for i, page := range pages {
+		if serial[i], err = zl.CreateOutputImage(context.Background(), page, inputs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	concurrent := make([]image.Image, len(pages))
+	var wg sync.WaitGroup
+	for i, page := range pages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			plan, err := zl.PlanPage(page, inputs)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if concurrent[i], err = plan.Draw(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range pages {
+		if !bytes.Equal(serial[i].(*image.RGBA).Pix, concurrent[i].(*image.RGBA).Pix) {
+			t.Errorf("page %s differs when rendered concurrently", pages[i].ID)
+		}
+	}
+}
+
+func TestCreateOutputImageCancelled(t *testing.T) {
+	zl, err := ImposeSaddleStitch(ImposeOptions{Pages: 8, PagesPerSide: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zl.Global.PPI = 20
+	inputs, err := GenerateTestImages(zl.InputCount(), 85, 110)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := zl.CreateOutputImage(ctx, zl.OutputPages[0], inputs); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, expected context.Canceled", err)
+	}
+}
+
+// BenchmarkCreateOutputImage renders all output pages of each example spec
+// with test inputs of a quarter letter page at the spec PPI.
+func BenchmarkCreateOutputImage(b *testing.B) {
+	// keep the debug and trace logs of the layout code out of the results
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	defer zerolog.SetGlobalLevel(level)
+
+	for _, spec := range loadExampleSpecs(b) {
+		zl := spec.zl
+		b.Run(spec.name, func(b *testing.B) {
+			inputs, err := GenerateTestImages(zl.InputCount(), int(4.25*zl.Global.PPI), int(5.5*zl.Global.PPI))
+			if err != nil {
+				b.Fatal(err)
+			}
+			pages, err := zl.ExpandOutputPages(len(inputs))
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, page := range pages {
+					if _, err := zl.CreateOutputImage(context.Background(), page, inputs); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
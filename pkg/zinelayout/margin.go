@@ -70,8 +70,6 @@ func (m *Margin) String() string {
 
 func (m *Margin) ComputePixelValues(ppi float64) error {
 	m.PPI = ppi
-	uc := parser.UnitConverter{PPI: ppi}
-	p := parser.ExpressionParser{PPI: ppi}
 
 	for _, mv := range []*MarginValue{&m.Top, &m.Bottom, &m.Left, &m.Right} {
 		log.Trace().
@@ -83,15 +81,7 @@ func (m *Margin) ComputePixelValues(ppi float64) error {
 			mv.Pixels = 0
 			continue
 		}
-		val, err := p.Parse(mv.Expression)
-		if err != nil {
-			return err
-		}
-		log.Trace().
-			Str("value", val.String()).
-			Msg("MarginValue after parse")
-
-		pixels, err := uc.ToPixels(val.Val, val.Unit)
+		pixels, err := ExpressionToPixels(mv.Expression, ppi)
 		if err != nil {
 			return err
 		}
@@ -108,23 +98,29 @@ func (m *Margin) ComputePixelValues(ppi float64) error {
 	return nil
 }
 
+// ExpressionToPixels evaluates a unit expression such as "1in + 3mm" and
+// converts the result to pixels at the given PPI.
+func ExpressionToPixels(expression string, ppi float64) (float64, error) {
+	p := parser.ExpressionParser{PPI: ppi}
+	uc := parser.UnitConverter{PPI: ppi}
+	val, err := p.Parse(expression)
+	if err != nil {
+		return 0, err
+	}
+	return uc.ToPixels(val.Val, val.Unit)
+}
+
 func (mv *MarginValue) UpdatePixels(pixels int, ppi float64) {
 	mv.Pixels = pixels
 	mv.Expression = fmt.Sprintf("%dpx", pixels)
 }
 
 func (mv *MarginValue) UpdateExpression(expression string, ppi float64) error {
-	p := parser.ExpressionParser{PPI: ppi}
-	uc := parser.UnitConverter{PPI: ppi}
-	val, err := p.Parse(expression)
+	pixels, err := ExpressionToPixels(expression, ppi)
 	if err != nil {
 		return err
 	}
 	mv.Expression = expression
-	pixels, err := uc.ToPixels(val.Val, val.Unit)
-	if err != nil {
-		return err
-	}
 	mv.Pixels = int(pixels)
 	return nil
 }
@@ -0,0 +1,88 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+)
+
+// Offset moves the content of an output page without changing the sheet
+// size. X and Y are unit expressions; positive values move right and down.
+type Offset struct {
+	X string `yaml:"x,omitempty"`
+	Y string `yaml:"y,omitempty"`
+}
+
+// pixels returns the offset in pixels at the given PPI.
+func (o *Offset) pixels(ppi float64) (image.Point, error) {
+	if o == nil {
+		return image.Point{}, nil
+	}
+	var p image.Point
+	for _, v := range []struct {
+		expr string
+		dst  *int
+	}{{o.X, &p.X}, {o.Y, &p.Y}} {
+		if strings.TrimSpace(v.expr) == "" {
+			continue
+		}
+		px, err := ExpressionToPixels(v.expr, ppi)
+		if err != nil {
+			return image.Point{}, err
+		}
+		*v.dst = int(px)
+	}
+	return p, nil
+}
+
+// PageSide tells which side of a duplex sheet an output page is printed on
+type PageSide string
+
+const (
+	PageSideFront PageSide = "front"
+	PageSideBack  PageSide = "back"
+)
+
+// PrinterProfile holds corrections for a specific printer that apply to
+// every spec printed on it.
+type PrinterProfile struct {
+	Name string `yaml:"name,omitempty"`
+	// DuplexOffset is added to the offset of every back side page, to line
+	// it up with the front side.
+	DuplexOffset *Offset `yaml:"duplex_offset,omitempty"`
+}
+
+// pageOffset returns the total offset of an output page in pixels.
+func (zl *ZineLayout) pageOffset(outputPage *OutputPage) (image.Point, error) {
+	offset, err := outputPage.Offset.pixels(zl.Global.PPI)
+	if err != nil {
+		return image.Point{}, fmt.Errorf("offset of output page %s: %w", outputPage.ID, err)
+	}
+	switch PageSide(strings.ToLower(string(outputPage.Side))) {
+	case "", PageSideFront:
+	case PageSideBack:
+		if zl.Global.Printer != nil {
+			duplex, err := zl.Global.Printer.DuplexOffset.pixels(zl.Global.PPI)
+			if err != nil {
+				return image.Point{}, fmt.Errorf("printer duplex offset: %w", err)
+			}
+			offset = offset.Add(duplex)
+		}
+	default:
+		return image.Point{}, fmt.Errorf("invalid side of output page %s: %s", outputPage.ID, outputPage.Side)
+	}
+	return offset, nil
+}
+
+// shiftImage moves the content of img by offset, keeping its size and
+// filling the uncovered area with white.
+func shiftImage(img *image.RGBA, offset image.Point) *image.RGBA {
+	if offset == (image.Point{}) {
+		return img
+	}
+	shifted := image.NewRGBA(img.Bounds())
+	draw.Draw(shifted, shifted.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(shifted, img.Bounds().Add(offset), img, img.Bounds().Min, draw.Src)
+	return shifted
+}
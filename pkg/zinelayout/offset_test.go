@@ -0,0 +1,35 @@
+package zinelayout
+
+import (
+	"image"
+	"testing"
+)
+
+func TestPageOffset(t *testing.T) {
+	zl := &ZineLayout{Global: &Global{
+		PPI:     100,
+		Printer: &PrinterProfile{DuplexOffset: &Offset{X: "0.1in", Y: "-0.05in"}},
+	}}
+	tests := []struct {
+		page     *OutputPage
+		expected image.Point
+	}{
+		{&OutputPage{ID: "front", Side: PageSideFront, Offset: &Offset{Y: "0.2in"}}, image.Pt(0, 20)},
+		{&OutputPage{ID: "back", Side: PageSideBack}, image.Pt(10, -5)},
+		{&OutputPage{ID: "back-offset", Side: PageSideBack, Offset: &Offset{X: "-0.1in"}}, image.Pt(0, -5)},
+		{&OutputPage{ID: "plain"}, image.Point{}},
+	}
+	for _, tt := range tests {
+		got, err := zl.pageOffset(tt.page)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.page.ID, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: offset = %v, expected %v", tt.page.ID, got, tt.expected)
+		}
+	}
+
+	if _, err := zl.pageOffset(&OutputPage{ID: "bad", Side: "inside"}); err == nil {
+		t.Errorf("expected error for invalid side")
+	}
+}
@@ -0,0 +1,102 @@
+package zinelayout
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Orientation of the output sheet
+type Orientation string
+
+const (
+	OrientationPortrait  Orientation = "portrait"
+	OrientationLandscape Orientation = "landscape"
+)
+
+// paperSizes maps named paper sizes to their portrait width and height.
+var paperSizes = map[string][2]string{
+	"a3":      {"297mm", "420mm"},
+	"a4":      {"210mm", "297mm"},
+	"a5":      {"148mm", "210mm"},
+	"letter":  {"8.5in", "11in"},
+	"legal":   {"8.5in", "14in"},
+	"tabloid": {"11in", "17in"},
+}
+
+// Paper describes the physical size of the output sheet. Either a named Size
+// or a custom Width and Height (as unit expressions) can be given.
+type Paper struct {
+	Size        string      `yaml:"size,omitempty"`
+	Width       string      `yaml:"width,omitempty"`
+	Height      string      `yaml:"height,omitempty"`
+	Orientation Orientation `yaml:"orientation,omitempty"`
+
+	WidthPixels  int `yaml:"-"`
+	HeightPixels int `yaml:"-"`
+}
+
+// ComputePixelValues resolves the paper size to pixels at the given PPI.
+func (p *Paper) ComputePixelValues(ppi float64) error {
+	return p.computePixelValues(ppi, "")
+}
+
+// computePixelValues resolves the paper size to pixels at the given PPI,
+// turning it to fallback when the paper has no orientation of its own.
+func (p *Paper) computePixelValues(ppi float64, fallback Orientation) error {
+	width, height := p.Width, p.Height
+	if p.Size != "" {
+		size, ok := paperSizes[strings.ToLower(p.Size)]
+		if !ok {
+			return fmt.Errorf("unknown paper size: %s", p.Size)
+		}
+		width, height = size[0], size[1]
+	}
+	if strings.TrimSpace(width) == "" || strings.TrimSpace(height) == "" {
+		return fmt.Errorf("paper needs either a size or both width and height")
+	}
+
+	w, err := ExpressionToPixels(width, ppi)
+	if err != nil {
+		return fmt.Errorf("paper width: %w", err)
+	}
+	h, err := ExpressionToPixels(height, ppi)
+	if err != nil {
+		return fmt.Errorf("paper height: %w", err)
+	}
+	if w <= 0 || h <= 0 {
+		return fmt.Errorf("paper size must be positive, got %s x %s", width, height)
+	}
+
+	orientation := p.Orientation
+	if orientation == "" {
+		orientation = fallback
+	}
+	switch Orientation(strings.ToLower(string(orientation))) {
+	case "":
+	case OrientationPortrait:
+		if w > h {
+			w, h = h, w
+		}
+	case OrientationLandscape:
+		if h > w {
+			w, h = h, w
+		}
+	default:
+		return fmt.Errorf("invalid paper orientation: %s", orientation)
+	}
+
+	p.WidthPixels = int(w)
+	p.HeightPixels = int(h)
+	return nil
+}
+
+func (p *Paper) String() string {
+	name := p.Size
+	if name == "" {
+		name = fmt.Sprintf("%s x %s", p.Width, p.Height)
+	}
+	if p.Orientation != "" {
+		name += " " + string(p.Orientation)
+	}
+	return fmt.Sprintf("%s (%dx%dpx)", name, p.WidthPixels, p.HeightPixels)
+}
@@ -0,0 +1,80 @@
+package zinelayout
+
+import (
+	"image"
+	"testing"
+)
+
+func TestPaperComputePixelValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		paper    Paper
+		expected image.Point
+		hasError bool
+	}{
+		{"named size", Paper{Size: "Letter"}, image.Pt(850, 1100), false},
+		{"landscape", Paper{Size: "letter", Orientation: OrientationLandscape}, image.Pt(1100, 850), false},
+		{"portrait of a wide custom size", Paper{Width: "11in", Height: "8.5in", Orientation: "Portrait"}, image.Pt(850, 1100), false},
+		{"custom size keeps its shape", Paper{Width: "11in", Height: "8.5in"}, image.Pt(1100, 850), false},
+		{"size wins over width and height", Paper{Size: "a5", Width: "1in", Height: "1in"}, image.Pt(582, 826), false},
+		{"unknown size", Paper{Size: "b5"}, image.Point{}, true},
+		{"missing height", Paper{Width: "8in"}, image.Point{}, true},
+		{"invalid orientation", Paper{Size: "a4", Orientation: "upright"}, image.Point{}, true},
+	}
+
+	for _, tt := range tests {
+		p := tt.paper
+		err := p.ComputePixelValues(100)
+		if tt.hasError {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got := image.Pt(p.WidthPixels, p.HeightPixels); got != tt.expected {
+			t.Errorf("%s: got %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+// TestPageSetupOrientation checks that the page_setup orientation turns a
+// paper without an orientation, and that the paper orientation wins.
+func TestPageSetupOrientation(t *testing.T) {
+	tests := []struct {
+		setup    Orientation
+		paper    Orientation
+		expected image.Point
+	}{
+		{"", "", image.Pt(850, 1100)},
+		{OrientationLandscape, "", image.Pt(1100, 850)},
+		{"", OrientationLandscape, image.Pt(1100, 850)},
+		{OrientationLandscape, OrientationPortrait, image.Pt(850, 1100)},
+		{OrientationPortrait, OrientationLandscape, image.Pt(1100, 850)},
+	}
+
+	for _, tt := range tests {
+		zl := &ZineLayout{
+			Global:    &Global{PPI: 100},
+			PageSetup: &PageSetup{Orientation: tt.setup, Paper: &Paper{Size: "letter", Orientation: tt.paper}},
+		}
+		if err := zl.PrepareGeometry(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := image.Pt(zl.PageSetup.Paper.WidthPixels, zl.PageSetup.Paper.HeightPixels); got != tt.expected {
+			t.Errorf("page_setup %q, paper %q: got %v, expected %v", tt.setup, tt.paper, got, tt.expected)
+		}
+		if zl.PageSetup.Paper.Orientation != tt.paper {
+			t.Errorf("page_setup %q, paper %q: the paper orientation changed to %q", tt.setup, tt.paper, zl.PageSetup.Paper.Orientation)
+		}
+	}
+
+	// without paper the page_setup orientation has nothing to turn
+	zl := &ZineLayout{Global: &Global{PPI: 100}, PageSetup: &PageSetup{Orientation: OrientationLandscape}}
+	if err := zl.PrepareGeometry(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package zinelayout
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// PrepareInputs converts decoded input images to *image.RGBA, keeping
+// *image.Gray, so that rotating, flipping and compositing them works on
+// their pixel buffers. Images that already have one of these types are
+// returned as is. Call it once after decoding the inputs of a render.
+func PrepareInputs(images []image.Image) []image.Image {
+	prepared := make([]image.Image, len(images))
+	for i, img := range images {
+		prepared[i] = rasterImage(img)
+	}
+	return prepared
+}
+
+// rasterImage returns img as an *image.RGBA or *image.Gray, copying it into
+// a new RGBA image when it has any other type.
+func rasterImage(img image.Image) image.Image {
+	switch img.(type) {
+	case *image.RGBA, *image.Gray:
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	return dst
+}
+
+// pixelBuffer is the pixel buffer of an *image.RGBA or *image.Gray, with
+// bpp bytes per pixel. Offsets are relative to the top-left pixel of rect,
+// so sub-images work like images at the origin.
+type pixelBuffer struct {
+	pix    []uint8
+	stride int
+	bpp    int
+	rect   image.Rectangle
+}
+
+// pixelsOf returns the pixel buffer of img, converting it first when it is
+// neither RGBA nor gray.
+func pixelsOf(img image.Image) pixelBuffer {
+	switch img := rasterImage(img).(type) {
+	case *image.Gray:
+		return pixelBuffer{pix: img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):], stride: img.Stride, bpp: 1, rect: img.Rect}
+	case *image.RGBA:
+		return pixelBuffer{pix: img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):], stride: img.Stride, bpp: 4, rect: img.Rect}
+	}
+	panic("unreachable")
+}
+
+// newPixels allocates an image of the same type as p with the given size at
+// the origin.
+func (p pixelBuffer) newPixels(size image.Point) (image.Image, pixelBuffer) {
+	r := image.Rectangle{Max: size}
+	if p.bpp == 1 {
+		img := image.NewGray(r)
+		return img, pixelBuffer{pix: img.Pix, stride: img.Stride, bpp: 1, rect: r}
+	}
+	img := image.NewRGBA(r)
+	return img, pixelBuffer{pix: img.Pix, stride: img.Stride, bpp: 4, rect: r}
+}
+
+// row returns the pixels of row y, counted from the top of the buffer.
+func (p pixelBuffer) row(y int) []uint8 {
+	return p.pix[y*p.stride : y*p.stride+p.rect.Dx()*p.bpp]
+}
+
+// fillRect sets all pixels of img inside r to c.
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Rect)
+	if r.Empty() {
+		return
+	}
+	first := img.Pix[img.PixOffset(r.Min.X, r.Min.Y):][:4*r.Dx()]
+	for i := 0; i < len(first); i += 4 {
+		first[0+i], first[1+i], first[2+i], first[3+i] = c.R, c.G, c.B, c.A
+	}
+	for y := r.Min.Y + 1; y < r.Max.Y; y++ {
+		copy(img.Pix[img.PixOffset(r.Min.X, y):], first)
+	}
+}
+
+// rowBatch is the number of rows drawn between two checks for
+// cancellation.
+const rowBatch = 128
+
+// forRowBatches calls fn with consecutive bands of at most rowBatch rows of
+// r. It stops with the error of ctx once ctx is cancelled.
+func forRowBatches(ctx context.Context, r image.Rectangle, fn func(band image.Rectangle)) error {
+	for y := r.Min.Y; y < r.Max.Y; y += rowBatch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fn(image.Rect(r.Min.X, y, r.Max.X, intMin(y+rowBatch, r.Max.Y)))
+	}
+	return nil
+}
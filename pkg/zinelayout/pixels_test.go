@@ -0,0 +1,80 @@
+package zinelayout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestPixelsGray checks that the pixel buffer code keeps gray images gray
+// and moves their pixels like it moves RGBA pixels.
+func TestPixelsGray(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(2, 3, 6, 6))
+	gray := image.NewGray(rgba.Rect)
+	for y := rgba.Rect.Min.Y; y < rgba.Rect.Max.Y; y++ {
+		for x := rgba.Rect.Min.X; x < rgba.Rect.Max.X; x++ {
+			v := uint8(10*y + x)
+			rgba.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+			gray.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+
+	ops := map[string]func(image.Image) image.Image{
+		"rotate 90":  func(img image.Image) image.Image { return rotateImage(img, 90) },
+		"rotate 180": func(img image.Image) image.Image { return rotateImage(img, 180) },
+		"rotate 270": func(img image.Image) image.Image { return rotateImage(img, 270) },
+		"flip h":     func(img image.Image) image.Image { return flipImage(img, FlipHorizontal) },
+		"flip v":     func(img image.Image) image.Image { return flipImage(img, FlipVertical) },
+		"mirror":     func(img image.Image) image.Image { return mirrorExtend(img, 5) },
+	}
+	for name, op := range ops {
+		fromRGBA, fromGray := op(rgba), op(gray)
+		if _, ok := fromGray.(*image.Gray); !ok {
+			t.Errorf("%s: got %T for a gray input", name, fromGray)
+			continue
+		}
+		b := fromRGBA.Bounds()
+		if fromGray.Bounds() != b {
+			t.Errorf("%s: bounds %v and %v differ", name, fromGray.Bounds(), b)
+			continue
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if got, expected := fromGray.(*image.Gray).GrayAt(x, y).Y, fromRGBA.(*image.RGBA).RGBAAt(x, y).R; got != expected {
+					t.Errorf("%s: pixel (%d, %d) = %d, expected %d", name, x, y, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestFillRect(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	red := color.RGBA{255, 0, 0, 255}
+	fillRect(img, image.Rect(2, -1, 6, 2), red)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x >= 2 && y < 2) != (img.RGBAAt(x, y) == red) {
+				t.Errorf("pixel (%d, %d) = %v", x, y, img.RGBAAt(x, y))
+			}
+		}
+	}
+}
+
+func TestPrepareInputs(t *testing.T) {
+	nrgba := image.NewNRGBA(image.Rect(1, 1, 3, 3))
+	nrgba.SetNRGBA(1, 1, color.NRGBA{255, 0, 0, 255})
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+
+	prepared := PrepareInputs([]image.Image{nrgba, gray})
+	rgba, ok := prepared[0].(*image.RGBA)
+	if !ok {
+		t.Fatalf("got %T, expected *image.RGBA", prepared[0])
+	}
+	if rgba.Bounds() != image.Rect(0, 0, 2, 2) || rgba.RGBAAt(0, 0) != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("converted image has bounds %v and top-left pixel %v", rgba.Bounds(), rgba.RGBAAt(0, 0))
+	}
+	if prepared[1] != image.Image(gray) {
+		t.Errorf("expected the gray image to be kept")
+	}
+}
@@ -1,54 +1,143 @@
 package zinelayout
 
 import (
+	"fmt"
 	"image"
+	"strings"
 )
 
-// rotateImage handles image rotation
-func rotateImage(img image.Image, degrees int) image.Image {
+// normalizeRotation maps a rotation in degrees onto 0, 90, 180 or 270.
+// Negative angles are accepted, so -90 is the same as 270.
+func normalizeRotation(degrees int) (int, error) {
+	r := ((degrees % 360) + 360) % 360
+	switch r {
+	case 0, 90, 180, 270:
+		return r, nil
+	default:
+		return 0, fmt.Errorf("rotation must be a multiple of 90 degrees")
+	}
+}
+
+// rotatedSize returns the size of an image of the given size after rotation.
+func rotatedSize(size image.Point, degrees int) image.Point {
+	if degrees == 90 || degrees == 270 {
+		return image.Point{X: size.Y, Y: size.X}
+	}
+	return size
+}
+
+// rotateMargin returns a copy of m with its sides moved the same way
+// rotateImage moves the edges of an image. Rotations are clockwise, so at 90
+// degrees the top side of the input ends up on the right of the output.
+func rotateMargin(m *Margin, degrees int) *Margin {
+	if m == nil {
+		return &Margin{}
+	}
+	r := *m
 	switch degrees {
-	case 0:
-		return img
 	case 90:
-		return rotate90(img)
+		r.Top, r.Right, r.Bottom, r.Left = m.Left, m.Top, m.Right, m.Bottom
 	case 180:
-		return rotate180(img)
+		r.Top, r.Right, r.Bottom, r.Left = m.Bottom, m.Left, m.Top, m.Right
 	case 270:
-		return rotate270(img)
+		r.Top, r.Right, r.Bottom, r.Left = m.Right, m.Bottom, m.Left, m.Top
+	}
+	return &r
+}
+
+// Flip mirrors an input page before it is rotated
+type Flip string
+
+const (
+	FlipNone Flip = ""
+	// FlipHorizontal mirrors left and right.
+	FlipHorizontal Flip = "horizontal"
+	// FlipVertical mirrors top and bottom.
+	FlipVertical Flip = "vertical"
+)
+
+// ParseFlip converts a string to a Flip
+func ParseFlip(s string) (Flip, error) {
+	switch f := Flip(strings.ToLower(strings.TrimSpace(s))); f {
+	case FlipNone, FlipHorizontal, FlipVertical:
+		return f, nil
 	default:
-		return img
+		return "", fmt.Errorf("invalid flip: %s", s)
 	}
 }
 
-func rotate90(img image.Image) image.Image {
-	bounds := img.Bounds()
-	newImg := image.NewRGBA(image.Rect(0, 0, bounds.Dy(), bounds.Dx()))
-	for x := bounds.Min.X; x < bounds.Max.X; x++ {
-		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-			newImg.Set(bounds.Max.Y-y-1, x, img.At(x, y))
-		}
+// flipMargin returns a copy of m with its sides mirrored like flipImage
+// mirrors the edges of an image.
+func flipMargin(m *Margin, flip Flip) *Margin {
+	if m == nil {
+		return nil
 	}
-	return newImg
+	r := *m
+	switch flip {
+	case FlipHorizontal:
+		r.Left, r.Right = m.Right, m.Left
+	case FlipVertical:
+		r.Top, r.Bottom = m.Bottom, m.Top
+	case FlipNone:
+	}
+	return &r
 }
 
-func rotate180(img image.Image) image.Image {
-	bounds := img.Bounds()
-	newImg := image.NewRGBA(bounds)
-	for x := bounds.Min.X; x < bounds.Max.X; x++ {
-		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-			newImg.Set(bounds.Max.X-x-1, bounds.Max.Y-y-1, img.At(x, y))
+// flipImage mirrors img horizontally or vertically. It works on the pixel
+// buffer of a copy of the image.
+func flipImage(img image.Image, flip Flip) image.Image {
+	if flip == FlipNone {
+		return img
+	}
+	src := pixelsOf(img)
+	w, h, bpp := src.rect.Dx(), src.rect.Dy(), src.bpp
+	flipped, dst := src.newPixels(image.Pt(w, h))
+
+	switch flip {
+	case FlipHorizontal:
+		for y := 0; y < h; y++ {
+			from, to := src.row(y), dst.row(y)
+			for x, r := 0, bpp*(w-1); x < w*bpp; x, r = x+bpp, r-bpp {
+				copy(to[r:r+bpp], from[x:x+bpp])
+			}
+		}
+	case FlipVertical:
+		for y := 0; y < h; y++ {
+			copy(dst.row(h-1-y), src.row(y))
 		}
+	case FlipNone:
 	}
-	return newImg
+	return flipped
 }
 
-func rotate270(img image.Image) image.Image {
-	bounds := img.Bounds()
-	newImg := image.NewRGBA(image.Rect(0, 0, bounds.Dy(), bounds.Dx()))
-	for x := bounds.Min.X; x < bounds.Max.X; x++ {
-		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-			newImg.Set(y, bounds.Max.X-x-1, img.At(x, y))
+// rotateImage rotates img clockwise by 0, 90, 180 or 270 degrees. Each row
+// of the source is copied pixel by pixel along a column or row of the
+// result.
+func rotateImage(img image.Image, degrees int) image.Image {
+	if degrees != 90 && degrees != 180 && degrees != 270 {
+		return img
+	}
+	src := pixelsOf(img)
+	w, h, bpp := src.rect.Dx(), src.rect.Dy(), src.bpp
+	rotated, dst := src.newPixels(rotatedSize(image.Pt(w, h), degrees))
+
+	for y := 0; y < h; y++ {
+		// i is the offset of the first pixel of source row y in the result,
+		// step the distance between consecutive pixels of the row there.
+		var i, step int
+		switch degrees {
+		case 90:
+			i, step = (h-1-y)*bpp, dst.stride
+		case 180:
+			i, step = (h-1-y)*dst.stride+(w-1)*bpp, -bpp
+		case 270:
+			i, step = (w-1)*dst.stride+y*bpp, -dst.stride
+		}
+		row := src.row(y)
+		for x := 0; x < len(row); x += bpp {
+			copy(dst.pix[i:i+bpp], row[x:x+bpp])
+			i += step
 		}
 	}
-	return newImg
+	return rotated
 }
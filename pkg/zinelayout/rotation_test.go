@@ -0,0 +1,117 @@
+package zinelayout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestNormalizeRotation(t *testing.T) {
+	tests := []struct {
+		input    int
+		expected int
+		hasError bool
+	}{
+		{0, 0, false},
+		{90, 90, false},
+		{180, 180, false},
+		{270, 270, false},
+		{360, 0, false},
+		{-90, 270, false},
+		{450, 90, false},
+		{45, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeRotation(tt.input)
+		if tt.hasError {
+			if err == nil {
+				t.Errorf("normalizeRotation(%d): expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("normalizeRotation(%d): unexpected error: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("normalizeRotation(%d) = %d, expected %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestRotateMarginFollowsImage checks that a margin side ends up on the same
+// edge as the image pixels that were next to it before rotation.
+func TestRotateMarginFollowsImage(t *testing.T) {
+	// 3x2 image with a red pixel in the top-left corner
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	red := color.RGBA{255, 0, 0, 255}
+	img.Set(0, 0, red)
+
+	m := &Margin{Top: MarginValue{Pixels: 1}, Left: MarginValue{Pixels: 2}}
+
+	tests := []struct {
+		degrees int
+		corner  image.Point // where the red pixel ends up, relative to the rotated size
+		top     int
+		left    int
+		right   int
+		bottom  int
+	}{
+		{0, image.Point{0, 0}, 1, 2, 0, 0},
+		{90, image.Point{1, 0}, 2, 0, 1, 0},
+		{180, image.Point{2, 1}, 0, 0, 2, 1},
+		{270, image.Point{0, 2}, 0, 1, 0, 2},
+	}
+
+	for _, tt := range tests {
+		rotated := rotateImage(img, tt.degrees)
+		if rotated.Bounds().Size() != rotatedSize(img.Bounds().Size(), tt.degrees) {
+			t.Errorf("%d: rotated size %v does not match rotatedSize", tt.degrees, rotated.Bounds().Size())
+		}
+		if rotated.At(tt.corner.X, tt.corner.Y) != red {
+			t.Errorf("%d: expected red pixel at %v", tt.degrees, tt.corner)
+		}
+		rm := rotateMargin(m, tt.degrees)
+		if rm.Top.Pixels != tt.top || rm.Left.Pixels != tt.left || rm.Right.Pixels != tt.right || rm.Bottom.Pixels != tt.bottom {
+			t.Errorf("%d: got margin %s", tt.degrees, rm.String())
+		}
+	}
+}
+
+func TestFlipImage(t *testing.T) {
+	// 3x2 image, not at the origin, with a red pixel in the top-left corner
+	img := image.NewRGBA(image.Rect(5, 5, 8, 7))
+	red := color.RGBA{255, 0, 0, 255}
+	img.Set(5, 5, red)
+
+	tests := []struct {
+		flip   Flip
+		corner image.Point
+	}{
+		{FlipHorizontal, image.Point{2, 0}},
+		{FlipVertical, image.Point{0, 1}},
+	}
+	for _, tt := range tests {
+		flipped := flipImage(img, tt.flip)
+		b := flipped.Bounds()
+		if b.Size() != img.Bounds().Size() {
+			t.Fatalf("flip %s: size = %v, expected %v", tt.flip, b.Size(), img.Bounds().Size())
+		}
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < b.Dx(); x++ {
+				got := color.RGBAModel.Convert(flipped.At(b.Min.X+x, b.Min.Y+y)).(color.RGBA)
+				if (image.Point{x, y} == tt.corner) != (got == red) {
+					t.Errorf("flip %s: pixel (%d, %d) = %v", tt.flip, x, y, got)
+				}
+			}
+		}
+	}
+
+	m := flipMargin(&Margin{Top: MarginValue{Pixels: 1}, Left: MarginValue{Pixels: 2}}, FlipHorizontal)
+	if m.Right.Pixels != 2 || m.Left.Pixels != 0 || m.Top.Pixels != 1 {
+		t.Errorf("flipMargin horizontal = %+v", m)
+	}
+	if _, err := ParseFlip("diagonal"); err == nil {
+		t.Errorf("expected error for invalid flip")
+	}
+}
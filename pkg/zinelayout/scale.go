@@ -0,0 +1,95 @@
+package zinelayout
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ScaleMode controls how an input image is sized to its cell
+type ScaleMode string
+
+const (
+	// ScaleModeNone draws the input at its native pixel size.
+	ScaleModeNone ScaleMode = "none"
+	// ScaleModeFit scales the input to fit inside the cell, keeping its aspect ratio.
+	ScaleModeFit ScaleMode = "fit"
+	// ScaleModeFill scales the input to cover the cell, keeping its aspect ratio
+	// and cropping whatever sticks out.
+	ScaleModeFill ScaleMode = "fill"
+	// ScaleModeStretch scales the input to exactly the cell size.
+	ScaleModeStretch ScaleMode = "stretch"
+)
+
+// ParseScaleMode converts a string to a ScaleMode
+func ParseScaleMode(s string) (ScaleMode, error) {
+	switch m := ScaleMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case ScaleModeNone, ScaleModeFit, ScaleModeFill, ScaleModeStretch:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid scale mode: %s", s)
+	}
+}
+
+// scaledSize returns the size an image of the given size is drawn at inside
+// bounds for the given mode.
+func scaledSize(mode ScaleMode, size, bounds image.Point) image.Point {
+	switch mode {
+	case ScaleModeFit:
+		return fitSize(size, bounds)
+	case ScaleModeFill:
+		return fillSize(size, bounds)
+	case ScaleModeStretch:
+		return bounds
+	case ScaleModeNone:
+		return size
+	}
+	return size
+}
+
+// scaleImage resamples img to exactly width x height pixels.
+func scaleImage(img image.Image, width, height int) image.Image {
+	if img.Bounds().Dx() == width && img.Bounds().Dy() == height {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// fitSize returns the largest size with the aspect ratio of size that fits
+// into bounds.
+func fitSize(size, bounds image.Point) image.Point {
+	if size.X <= 0 || size.Y <= 0 {
+		return image.Point{}
+	}
+	if size.X*bounds.Y > bounds.X*size.Y {
+		return image.Point{X: bounds.X, Y: size.Y * bounds.X / size.X}
+	}
+	return image.Point{X: size.X * bounds.Y / size.Y, Y: bounds.Y}
+}
+
+// fillSize returns the smallest size with the aspect ratio of size that
+// covers bounds.
+func fillSize(size, bounds image.Point) image.Point {
+	if size.X <= 0 || size.Y <= 0 {
+		return image.Point{}
+	}
+	if size.X*bounds.Y > bounds.X*size.Y {
+		return image.Point{X: (size.X*bounds.Y + size.Y - 1) / size.Y, Y: bounds.Y}
+	}
+	return image.Point{X: bounds.X, Y: (size.Y*bounds.X + size.X - 1) / size.X}
+}
+
+// drawClipped draws src into rect on dst, leaving out everything outside
+// clip. It draws in batches of rows and stops when ctx is cancelled.
+func drawClipped(ctx context.Context, dst draw.Image, rect image.Rectangle, src image.Image, clip image.Rectangle) error {
+	return forRowBatches(ctx, rect.Intersect(clip), func(band image.Rectangle) {
+		sp := src.Bounds().Min.Add(band.Min.Sub(rect.Min))
+		draw.Draw(dst, band, src, sp, draw.Over)
+	})
+}
@@ -0,0 +1,89 @@
+package zinelayout
+
+import (
+	"image"
+	"testing"
+)
+
+func TestParseScaleMode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ScaleMode
+		hasError bool
+	}{
+		{"none", ScaleModeNone, false},
+		{"fit", ScaleModeFit, false},
+		{"Fit", ScaleModeFit, false},
+		{" fill", ScaleModeFill, false},
+		{"STRETCH ", ScaleModeStretch, false},
+		{"", "", true},
+		{"zoom", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseScaleMode(tt.input)
+		if tt.hasError {
+			if err == nil {
+				t.Errorf("ParseScaleMode(%q): expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseScaleMode(%q): unexpected error: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("ParseScaleMode(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestScaledSize(t *testing.T) {
+	tests := []struct {
+		mode     ScaleMode
+		size     image.Point
+		bounds   image.Point
+		expected image.Point
+	}{
+		// wider than the cell: fit is limited by the width, fill by the height
+		{ScaleModeFit, image.Pt(200, 100), image.Pt(100, 100), image.Pt(100, 50)},
+		{ScaleModeFill, image.Pt(200, 100), image.Pt(100, 100), image.Pt(200, 100)},
+		// taller than the cell
+		{ScaleModeFit, image.Pt(100, 300), image.Pt(200, 200), image.Pt(66, 200)},
+		{ScaleModeFill, image.Pt(100, 300), image.Pt(200, 200), image.Pt(200, 600)},
+		// smaller than the cell, fit and fill scale up
+		{ScaleModeFit, image.Pt(10, 20), image.Pt(100, 100), image.Pt(50, 100)},
+		{ScaleModeFill, image.Pt(10, 20), image.Pt(100, 100), image.Pt(100, 200)},
+		// fill rounds up so that it always covers the cell
+		{ScaleModeFill, image.Pt(3, 2), image.Pt(10, 10), image.Pt(15, 10)},
+		{ScaleModeFill, image.Pt(2, 3), image.Pt(10, 10), image.Pt(10, 15)},
+		{ScaleModeFill, image.Pt(7, 3), image.Pt(10, 10), image.Pt(24, 10)},
+		{ScaleModeStretch, image.Pt(200, 100), image.Pt(80, 120), image.Pt(80, 120)},
+		{ScaleModeNone, image.Pt(200, 100), image.Pt(80, 120), image.Pt(200, 100)},
+		// an empty input has no size to keep the aspect ratio of
+		{ScaleModeFit, image.Pt(0, 100), image.Pt(80, 120), image.Point{}},
+		{ScaleModeFill, image.Pt(100, 0), image.Pt(80, 120), image.Point{}},
+	}
+
+	for _, tt := range tests {
+		got := scaledSize(tt.mode, tt.size, tt.bounds)
+		if got != tt.expected {
+			t.Errorf("scaledSize(%s, %v, %v) = %v, expected %v", tt.mode, tt.size, tt.bounds, got, tt.expected)
+		}
+		if tt.mode == ScaleModeFit && (got.X > tt.bounds.X || got.Y > tt.bounds.Y) {
+			t.Errorf("fit %v into %v gave %v, which does not fit", tt.size, tt.bounds, got)
+		}
+		if tt.mode == ScaleModeFill && got != (image.Point{}) && (got.X < tt.bounds.X || got.Y < tt.bounds.Y) {
+			t.Errorf("fill %v over %v gave %v, which does not cover it", tt.size, tt.bounds, got)
+		}
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(5, 5, 25, 15))
+	if got := scaleImage(img, 20, 10); got != image.Image(img) {
+		t.Errorf("expected an image of the right size to be returned as is")
+	}
+	if got := scaleImage(img, 40, 7).Bounds(); got != image.Rect(0, 0, 40, 7) {
+		t.Errorf("scaled bounds = %v, expected (0,0)-(40,7)", got)
+	}
+}
@@ -0,0 +1,171 @@
+package zinelayout
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SignatureMode is how the signatures of a booklet are gathered
+type SignatureMode string
+
+const (
+	// SignatureModeStacked gathers the signatures one after the other, as
+	// for a sewn or perfect bound book. Each signature holds consecutive
+	// pages.
+	SignatureModeStacked SignatureMode = "stacked"
+	// SignatureModeNested inserts every signature into the middle of the
+	// previous one and saddle stitches them together. The first signature
+	// holds the first and the last pages of the booklet.
+	SignatureModeNested SignatureMode = "nested"
+)
+
+// ParseSignatureMode converts a string to a SignatureMode
+func ParseSignatureMode(s string) (SignatureMode, error) {
+	switch m := SignatureMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case SignatureModeStacked, SignatureModeNested:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid signature mode: %s", s)
+	}
+}
+
+// Signatures splits a long input set into folded signatures that are
+// printed, folded and gathered one after the other. The output pages of the
+// spec describe a single signature; input indexes 1 to Pages refer to the
+// pages of that signature and are shifted by Pages for every following one.
+type Signatures struct {
+	// Pages is the number of input pages in one signature.
+	Pages int `yaml:"pages"`
+	// Count is the number of signatures. It defaults to as many as needed to
+	// hold all input images.
+	Count int `yaml:"count,omitempty"`
+	// Mode is how the signatures are gathered, stacked by default.
+	Mode SignatureMode `yaml:"mode,omitempty"`
+}
+
+// inputIndex returns the input index that index i of a signature refers to
+// in signature sig, counted from 0, of count signatures.
+func (s *Signatures) inputIndex(mode SignatureMode, sig, count, i int) int {
+	if mode != SignatureModeNested {
+		return i + sig*s.Pages
+	}
+	// the first half of a nested signature comes after the first halves
+	// of the signatures around it, the second half before their second
+	// halves
+	half := s.Pages / 2
+	if i <= half {
+		return i + sig*half
+	}
+	return count*s.Pages - (s.Pages - i) - sig*half
+}
+
+// SignatureCount returns the number of signatures needed for inputCount
+// input images.
+func (s *Signatures) SignatureCount(inputCount int) int {
+	if s.Count > 0 {
+		return s.Count
+	}
+	n := (inputCount + s.Pages - 1) / s.Pages
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// InputCount returns the number of input images the layout refers to: the
+// largest input index used by the output pages, times the signature count
+// when signatures are set.
+func (zl *ZineLayout) InputCount() int {
+	maxIndex := 0
+	for _, op := range zl.OutputPages {
+		for _, l := range op.Layout {
+			if l.InputIndex > maxIndex {
+				maxIndex = l.InputIndex
+			}
+		}
+	}
+	if zl.Signatures != nil && zl.Signatures.Pages > 0 {
+		return zl.Signatures.SignatureCount(maxIndex) * zl.Signatures.Pages
+	}
+	return maxIndex
+}
+
+// ExpandOutputPages returns the output pages to render for inputCount input
+// images. Without signatures these are the output pages of the spec.
+//
+// With signatures the output pages are repeated once per signature, with
+// input indexes offset by the pages of the preceding signatures and IDs
+// prefixed with "signature-NN-". Items past the last input image are left
+// out, so the booklet is padded with blank pages at the end.
+//
+// Nested signatures take their pages from both ends of the booklet, see
+// SignatureModeNested, and their sheets are deeper in the booklet for creep
+// compensation by Pages/4 sheets per enclosing signature.
+func (zl *ZineLayout) ExpandOutputPages(inputCount int) ([]*OutputPage, error) {
+	s := zl.Signatures
+	if s == nil {
+		return zl.OutputPages, nil
+	}
+	if s.Pages <= 0 {
+		return nil, fmt.Errorf("signature pages must be positive, got %d", s.Pages)
+	}
+	if s.Count < 0 {
+		return nil, fmt.Errorf("signature count must not be negative, got %d", s.Count)
+	}
+	mode := SignatureModeStacked
+	if s.Mode != "" {
+		m, err := ParseSignatureMode(string(s.Mode))
+		if err != nil {
+			return nil, err
+		}
+		mode = m
+	}
+	if mode == SignatureModeNested && s.Pages%4 != 0 {
+		return nil, fmt.Errorf("nested signatures need a multiple of 4 pages, got %d", s.Pages)
+	}
+	for _, op := range zl.OutputPages {
+		for _, l := range op.Layout {
+			if l.InputIndex < 1 || l.InputIndex > s.Pages {
+				return nil, fmt.Errorf("input index %d on output page %s is outside the %d pages of a signature",
+					l.InputIndex, op.ID, s.Pages)
+			}
+		}
+	}
+
+	// The copies share their margins with the spec pages, which is where
+	// PrepareGeometry resolves them. After it has run, no margin is nil.
+	for _, op := range zl.OutputPages {
+		if op.Margin == nil {
+			op.Margin = &Margin{}
+		}
+		for _, l := range op.Layout {
+			if l.Margin == nil {
+				l.Margin = &Margin{}
+			}
+		}
+	}
+
+	var pages []*OutputPage
+	count := s.SignatureCount(inputCount)
+	for sig := 0; sig < count; sig++ {
+		for _, op := range zl.OutputPages {
+			page := *op
+			page.ID = fmt.Sprintf("signature-%02d-%s", sig+1, op.ID)
+			page.Layout = nil
+			for _, l := range op.Layout {
+				index := s.inputIndex(mode, sig, count, l.InputIndex)
+				if index > inputCount {
+					continue // blank padding page
+				}
+				item := *l
+				item.InputIndex = index
+				if mode == SignatureModeNested && item.Spine != "" {
+					item.Sheet += sig * s.Pages / 4
+				}
+				page.Layout = append(page.Layout, &item)
+			}
+			pages = append(pages, &page)
+		}
+	}
+	return pages, nil
+}
@@ -0,0 +1,96 @@
+package zinelayout
+
+import (
+	"testing"
+)
+
+func TestExpandOutputPagesSignatures(t *testing.T) {
+	zl, err := ImposeSaddleStitch(ImposeOptions{Pages: 10, PagesPerSide: 2, SignaturePages: 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if zl.Signatures == nil || zl.Signatures.Pages != 8 || zl.Signatures.Count != 2 {
+		t.Fatalf("signatures = %+v, expected 2 signatures of 8 pages", zl.Signatures)
+	}
+
+	pages, err := zl.ExpandOutputPages(10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pages) != 8 {
+		t.Fatalf("got %d output pages, expected 8", len(pages))
+	}
+	if pages[4].ID != "signature-02-sheet-01-front" {
+		t.Errorf("ID = %s, expected signature-02-sheet-01-front", pages[4].ID)
+	}
+	// the outer sheet of the second signature holds pages 16 (blank) and 9
+	front := pages[4].Layout
+	if len(front) != 1 || front[0].InputIndex != 9 || front[0].Position.Column != 1 {
+		t.Errorf("second signature front = %+v, expected only input 9 in column 1", front)
+	}
+	// the spec pages are left untouched
+	if zl.OutputPages[0].Layout[1].InputIndex != 1 {
+		t.Errorf("spec page was modified: %+v", zl.OutputPages[0].Layout[1])
+	}
+	if got := zl.InputCount(); got != 16 {
+		t.Errorf("InputCount() = %d, expected 16", got)
+	}
+
+	zl.OutputPages[0].Layout[0].InputIndex = 9
+	if _, err := zl.ExpandOutputPages(10); err == nil {
+		t.Errorf("expected error for input index outside the signature")
+	}
+}
+
+// TestExpandOutputPagesNested checks that nested signatures place the
+// pages like a single saddle-stitched booklet of all their sheets.
+func TestExpandOutputPagesNested(t *testing.T) {
+	for _, pages := range []int{16, 14} {
+		nested, err := ImposeSaddleStitch(ImposeOptions{Pages: pages, PagesPerSide: 2, SignaturePages: 8, NestedSignatures: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		single, err := ImposeSaddleStitch(ImposeOptions{Pages: pages, PagesPerSide: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, err := nested.ExpandOutputPages(pages)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != len(single.OutputPages) {
+			t.Fatalf("%d pages: got %d output pages, expected %d", pages, len(got), len(single.OutputPages))
+		}
+		for i, page := range got {
+			expected := single.OutputPages[i]
+			if len(page.Layout) != len(expected.Layout) {
+				t.Errorf("%d pages: %s has %d items, expected %d like %s", pages, page.ID, len(page.Layout), len(expected.Layout), expected.ID)
+				continue
+			}
+			for j, item := range page.Layout {
+				e := expected.Layout[j]
+				if item.InputIndex != e.InputIndex || item.Position != e.Position || item.Sheet != e.Sheet {
+					t.Errorf("%d pages: %s item %d = input %d at %+v on sheet %d, expected input %d at %+v on sheet %d",
+						pages, page.ID, j, item.InputIndex, item.Position, item.Sheet, e.InputIndex, e.Position, e.Sheet)
+				}
+			}
+		}
+	}
+
+	zl, err := ImposeSaddleStitch(ImposeOptions{Pages: 16, PagesPerSide: 2, SignaturePages: 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zl.Signatures.Mode = "spiral"
+	if _, err := zl.ExpandOutputPages(16); err == nil {
+		t.Errorf("expected error for an invalid signature mode")
+	}
+	zl.Signatures = &Signatures{Pages: 6, Mode: "Nested"}
+	for _, op := range zl.OutputPages {
+		op.Layout = op.Layout[:0]
+	}
+	if _, err := zl.ExpandOutputPages(12); err == nil {
+		t.Errorf("expected error for nested signatures of 6 pages")
+	}
+}
@@ -0,0 +1,86 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// SplitDirection is the direction of the cuts that split a spread
+type SplitDirection string
+
+const (
+	// SplitVertical cuts along vertical lines, for pages side by side.
+	SplitVertical SplitDirection = "vertical"
+	// SplitHorizontal cuts along horizontal lines, for pages on top of each
+	// other.
+	SplitHorizontal SplitDirection = "horizontal"
+)
+
+// ParseSplitDirection converts a string to a SplitDirection
+func ParseSplitDirection(s string) (SplitDirection, error) {
+	switch d := SplitDirection(strings.ToLower(strings.TrimSpace(s))); d {
+	case SplitVertical, SplitHorizontal:
+		return d, nil
+	default:
+		return "", fmt.Errorf("invalid split direction: %s", s)
+	}
+}
+
+// SplitOptions describes how SplitImage cuts a spread into pages.
+type SplitOptions struct {
+	// Pages is the number of pages in each image.
+	Pages     int
+	Direction SplitDirection
+	// Gutter is the width of the strip between two pages that is thrown
+	// away, as a unit expression at PPI.
+	Gutter string
+	PPI    float64
+}
+
+// SplitImage cuts a spread into opts.Pages pages of the same size, in
+// reading order: left to right for vertical cuts, top to bottom for
+// horizontal ones. Pixels that don't divide evenly are dropped from the
+// far edge.
+func SplitImage(img image.Image, opts SplitOptions) ([]image.Image, error) {
+	if opts.Pages < 1 {
+		return nil, fmt.Errorf("pages must be at least 1, got %d", opts.Pages)
+	}
+	gutter := 0
+	if strings.TrimSpace(opts.Gutter) != "" {
+		px, err := ExpressionToPixels(opts.Gutter, opts.PPI)
+		if err != nil {
+			return nil, fmt.Errorf("gutter: %w", err)
+		}
+		if px < 0 {
+			return nil, fmt.Errorf("gutter must not be negative, got %s", opts.Gutter)
+		}
+		gutter = int(px)
+	}
+
+	b := img.Bounds()
+	length := b.Dx()
+	if opts.Direction == SplitHorizontal {
+		length = b.Dy()
+	}
+	size := (length - gutter*(opts.Pages-1)) / opts.Pages
+	if size <= 0 {
+		return nil, fmt.Errorf("a %d pixel image does not split into %d pages with a %d pixel gutter", length, opts.Pages, gutter)
+	}
+
+	pages := make([]image.Image, 0, opts.Pages)
+	for i := 0; i < opts.Pages; i++ {
+		start := i * (size + gutter)
+		var r image.Rectangle
+		switch opts.Direction {
+		case SplitVertical:
+			r = image.Rect(b.Min.X+start, b.Min.Y, b.Min.X+start+size, b.Max.Y)
+		case SplitHorizontal:
+			r = image.Rect(b.Min.X, b.Min.Y+start, b.Max.X, b.Min.Y+start+size)
+		default:
+			return nil, fmt.Errorf("invalid split direction: %s", opts.Direction)
+		}
+		pages = append(pages, cropImage(img, r))
+	}
+	return pages, nil
+}
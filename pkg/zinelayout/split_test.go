@@ -0,0 +1,45 @@
+package zinelayout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSplitImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 210, 100))
+	red := color.RGBA{255, 0, 0, 255}
+	img.Set(119, 0, red)
+
+	pages, err := SplitImage(img, SplitOptions{Pages: 2, Direction: SplitVertical, Gutter: "0.1in", PPI: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []image.Rectangle{image.Rect(0, 0, 100, 100), image.Rect(110, 0, 210, 100)}
+	for i, page := range pages {
+		if page.Bounds() != expected[i] {
+			t.Errorf("page %d: bounds = %v, expected %v", i+1, page.Bounds(), expected[i])
+		}
+	}
+	if pages[1].At(119, 0) != red {
+		t.Errorf("expected the marked pixel on the second page")
+	}
+
+	pages, err = SplitImage(img, SplitOptions{Pages: 3, Direction: SplitHorizontal})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pages) != 3 || pages[2].Bounds() != image.Rect(0, 66, 210, 99) {
+		t.Errorf("horizontal split = %d pages, last %v", len(pages), pages[len(pages)-1].Bounds())
+	}
+
+	for _, bad := range []SplitOptions{
+		{Pages: 0, Direction: SplitVertical},
+		{Pages: 2, Direction: "diagonal"},
+		{Pages: 2, Direction: SplitVertical, Gutter: "300px"},
+	} {
+		if _, err := SplitImage(img, bad); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package zinelayout
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// SVGOptions controls how CreateOutputSVG writes the input images.
+type SVGOptions struct {
+	// InputHrefs are the URLs of the input images by input index - 1,
+	// usually file paths relative to the SVG file. Inputs with an href are
+	// referenced instead of embedded as PNG data.
+	InputHrefs []string
+}
+
+// CreateOutputSVG lays out an output page like CreateOutputImage and writes
+// it to w as an SVG document.
+//
+// Inputs are image elements with a transform for their position, scale,
+// flip and rotation, clipped to their crop and artwork, so that they stay
+// editable in a vector editor. Text, borders, crop marks and guides are
+// vector elements. The document is sized in inches from the global PPI,
+// with one user unit per pixel of the PNG output.
+func (zl *ZineLayout) CreateOutputSVG(w io.Writer, outputPage *OutputPage, inputImages []image.Image, opts SVGOptions) error {
+	plan, err := zl.PlanPage(outputPage, inputImages)
+	if err != nil {
+		return err
+	}
+	return plan.WriteSVG(w, opts)
+}
+
+// WriteSVG writes the page to w as an SVG document, see CreateOutputSVG.
+func (p *PagePlan) WriteSVG(w io.Writer, opts SVGOptions) error {
+	sw := &svgWriter{w: bufio.NewWriter(w)}
+	if err := p.writeSVG(sw, opts); err != nil {
+		return err
+	}
+	if sw.err != nil {
+		return sw.err
+	}
+	return sw.w.Flush()
+}
+
+// svgWriter writes SVG markup and keeps the first write error.
+type svgWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (sw *svgWriter) printf(format string, args ...interface{}) {
+	if sw.err != nil {
+		return
+	}
+	_, sw.err = fmt.Fprintf(sw.w, format, args...)
+}
+
+func (p *PagePlan) writeSVG(sw *svgWriter, opts SVGOptions) error {
+	sw.printf("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	sw.printf("<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" version=\"1.1\" width=\"%sin\" height=\"%sin\" viewBox=\"0 0 %d %d\">\n",
+		svgNumber(float64(p.size.X)/p.ppi), svgNumber(float64(p.size.Y)/p.ppi), p.size.X, p.size.Y)
+	sw.printf("<title>%s</title>\n", svgEscape(p.page.ID))
+
+	// Clip paths of the items: the crop in the frame of the input, the
+	// artwork and trim box on the page
+	sw.printf("<defs>\n")
+	for i, item := range p.items {
+		if item.place.Empty() {
+			continue
+		}
+		if item.crop != item.input.Bounds() {
+			sw.printf("<clipPath id=\"crop-%d\">%s</clipPath>\n", i+1, svgRect(item.crop.Sub(item.input.Bounds().Min)))
+		}
+		sw.printf("<clipPath id=\"artwork-%d\">%s</clipPath>\n", i+1, svgRect(item.artwork))
+		if item.redraw {
+			sw.printf("<clipPath id=\"trim-%d\">%s</clipPath>\n", i+1, svgRect(item.trim))
+		}
+	}
+	sw.printf("</defs>\n")
+
+	sw.printf("<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", p.size.X, p.size.Y)
+	if p.offset != (image.Point{}) {
+		// Shift the content for printer registration
+		sw.printf("<g transform=\"translate(%d %d)\">\n", p.offset.X, p.offset.Y)
+	}
+
+	// Draw the inputs clipped to their bleed, then redraw the trim boxes
+	// that another item's bleed spilled into.
+	for i, item := range p.items {
+		if item.place.Empty() {
+			continue
+		}
+		href, err := svgInputHref(item, opts)
+		if err != nil {
+			return err
+		}
+		sw.printf("<g clip-path=\"url(#artwork-%d)\">\n<g id=\"item-%d\">\n", i+1, i+1)
+		clip := ""
+		if item.crop != item.input.Bounds() {
+			clip = fmt.Sprintf(" clip-path=\"url(#crop-%d)\"", i+1)
+		}
+		size := item.input.Bounds().Size()
+		sw.printf("<image id=\"image-%d\" width=\"%d\" height=\"%d\" preserveAspectRatio=\"none\" transform=\"%s\"%s xlink:href=\"%s\"/>\n",
+			i+1, size.X, size.Y, item.transform(), clip, href)
+		if item.mirror && item.bleed > 0 {
+			for _, m := range item.mirrorTransforms() {
+				sw.printf("<use xlink:href=\"#image-%d\" transform=\"%s\"/>\n", i+1, m)
+			}
+		}
+		sw.printf("</g>\n</g>\n")
+	}
+	for i, item := range p.items {
+		if !item.place.Empty() && item.redraw {
+			sw.printf("<use xlink:href=\"#item-%d\" clip-path=\"url(#trim-%d)\"/>\n", i+1, i+1)
+		}
+	}
+
+	for _, t := range p.texts {
+		if err := t.writeSVG(sw); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range p.borders {
+		b.writeSVG(sw)
+	}
+
+	if len(p.marks) > 0 {
+		sw.printf("<g %s>\n", svgStroke(p.markColor, 1))
+		for _, r := range p.marks {
+			// marks are one pixel wide, the line runs through its middle
+			if r.Dy() == 1 {
+				sw.printf("<line x1=\"%d\" y1=\"%s\" x2=\"%d\" y2=\"%s\"/>\n", r.Min.X, svgNumber(float64(r.Min.Y)+0.5), r.Max.X, svgNumber(float64(r.Min.Y)+0.5))
+			} else {
+				sw.printf("<line x1=\"%s\" y1=\"%d\" x2=\"%s\" y2=\"%d\"/>\n", svgNumber(float64(r.Min.X)+0.5), r.Min.Y, svgNumber(float64(r.Min.X)+0.5), r.Max.Y)
+			}
+		}
+		sw.printf("</g>\n")
+	}
+
+	if p.pageBorder != nil {
+		p.pageBorder.writeSVG(sw)
+	}
+
+	for _, g := range p.guides {
+		g.writeSVG(sw)
+	}
+
+	if p.offset != (image.Point{}) {
+		sw.printf("</g>\n")
+	}
+	if p.globalBorder != nil {
+		p.globalBorder.writeSVG(sw)
+	}
+	sw.printf("</svg>\n")
+	return nil
+}
+
+// svgInputHref returns the href of the input image of an item: its URL from
+// opts, or the input embedded as a PNG data URL.
+func svgInputHref(item *itemPlan, opts SVGOptions) (string, error) {
+	if i := item.layout.InputIndex - 1; i < len(opts.InputHrefs) && opts.InputHrefs[i] != "" {
+		return svgEscape(opts.InputHrefs[i]), nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, item.input); err != nil {
+		return "", fmt.Errorf("encoding input index %d: %w", item.layout.InputIndex, err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// affine is a 2D affine transform, mapping x, y to
+// a*x + c*y + e, b*x + d*y + f like an SVG matrix.
+type affine struct {
+	a, b, c, d, e, f float64
+}
+
+func translation(x, y float64) affine {
+	return affine{a: 1, d: 1, e: x, f: y}
+}
+
+func scaling(x, y float64) affine {
+	return affine{a: x, d: y}
+}
+
+// then returns the transform that applies m after t.
+func (t affine) then(m affine) affine {
+	return affine{
+		a: m.a*t.a + m.c*t.b,
+		b: m.b*t.a + m.d*t.b,
+		c: m.a*t.c + m.c*t.d,
+		d: m.b*t.c + m.d*t.d,
+		e: m.a*t.e + m.c*t.f + m.e,
+		f: m.b*t.e + m.d*t.f + m.f,
+	}
+}
+
+func (t affine) String() string {
+	return fmt.Sprintf("matrix(%s %s %s %s %s %s)",
+		svgNumber(t.a), svgNumber(t.b), svgNumber(t.c), svgNumber(t.d), svgNumber(t.e), svgNumber(t.f))
+}
+
+// transform maps the pixels of the whole input of an item to the page, the
+// same way the PNG renderer crops, flips, rotates and scales it into place.
+func (it *itemPlan) transform() affine {
+	size := it.crop.Size()
+	w, h := float64(size.X), float64(size.Y)
+	origin := it.crop.Min.Sub(it.input.Bounds().Min)
+	t := translation(-float64(origin.X), -float64(origin.Y))
+
+	switch it.flip {
+	case FlipHorizontal:
+		t = t.then(affine{a: -1, d: 1, e: w})
+	case FlipVertical:
+		t = t.then(affine{a: 1, d: -1, f: h})
+	case FlipNone:
+	}
+
+	// quarter turns clockwise, like rotateImage
+	switch it.rotation {
+	case 90:
+		t = t.then(affine{b: 1, c: -1, e: h})
+	case 180:
+		t = t.then(affine{a: -1, d: -1, e: w, f: h})
+	case 270:
+		t = t.then(affine{b: -1, c: 1, f: w})
+	}
+
+	rotated := rotatedSize(size, it.rotation)
+	t = t.then(scaling(float64(it.place.Dx())/float64(rotated.X), float64(it.place.Dy())/float64(rotated.Y)))
+	return t.then(translation(float64(it.place.Min.X), float64(it.place.Min.Y)))
+}
+
+// mirrorTransforms returns the reflections of a placed input across its
+// edges and corners that fill a mirrored bleed, like mirrorExtend.
+func (it *itemPlan) mirrorTransforms() []affine {
+	left, right := float64(it.place.Min.X), float64(it.place.Max.X)
+	top, bottom := float64(it.place.Min.Y), float64(it.place.Max.Y)
+	// reflections across no edge, the left and the right edge
+	xs := []affine{{a: 1, d: 1}, {a: -1, d: 1, e: 2 * left}, {a: -1, d: 1, e: 2 * right}}
+	// and across no edge, the top and the bottom edge
+	ys := []affine{{a: 1, d: 1}, {a: 1, d: -1, f: 2 * top}, {a: 1, d: -1, f: 2 * bottom}}
+	var transforms []affine
+	for i, x := range xs {
+		for j, y := range ys {
+			if i > 0 || j > 0 {
+				transforms = append(transforms, x.then(y))
+			}
+		}
+	}
+	return transforms
+}
+
+func (t *placedText) writeSVG(sw *svgWriter) error {
+	ascent, err := labelAscent(t.font, t.size)
+	if err != nil {
+		return err
+	}
+	family, weight, style, err := svgFont(t.font)
+	if err != nil {
+		return err
+	}
+
+	// Lines are laid out in the frame of the text before it is rotated
+	block := rotatedSize(t.rect.Size(), t.rotation)
+	lineHeight := float64(block.Y) / float64(len(t.lines))
+	transform := fmt.Sprintf("translate(%d %d)", t.rect.Min.X, t.rect.Min.Y)
+	if t.rotation != 0 {
+		center := t.rect.Min.Add(t.rect.Max)
+		transform = fmt.Sprintf("translate(%s %s) rotate(%d) translate(%s %s)",
+			svgNumber(float64(center.X)/2), svgNumber(float64(center.Y)/2), t.rotation,
+			svgNumber(-float64(block.X)/2), svgNumber(-float64(block.Y)/2))
+	}
+
+	x, anchor := float64(block.X)/2, "middle"
+	if strings.HasSuffix(string(t.align), "left") {
+		x, anchor = 0, "start"
+	}
+	if strings.HasSuffix(string(t.align), "right") {
+		x, anchor = float64(block.X), "end"
+	}
+
+	sw.printf("<g transform=\"%s\" font-family=\"%s\" font-size=\"%s\"", transform, svgEscape(family), svgNumber(t.size))
+	if weight != "" {
+		sw.printf(" font-weight=\"%s\"", weight)
+	}
+	if style != "" {
+		sw.printf(" font-style=\"%s\"", style)
+	}
+	sw.printf(" text-anchor=\"%s\" %s>\n", anchor, svgFill(t.color))
+	for i, line := range t.lines {
+		sw.printf("<text x=\"%s\" y=\"%s\" xml:space=\"preserve\">%s</text>\n",
+			svgNumber(x), svgNumber(float64(i)*lineHeight+ascent), svgEscape(line))
+	}
+	sw.printf("</g>\n")
+	return nil
+}
+
+// svgFont returns the font-family, font-weight and font-style of a font
+// name, see LoadFont. Weight and style are empty for regular fonts.
+func svgFont(name string) (string, string, string, error) {
+	switch strings.ToLower(name) {
+	case "", DefaultFont, "regular":
+		return "Go, sans-serif", "", "", nil
+	case "bold":
+		return "Go, sans-serif", "bold", "", nil
+	case "italic":
+		return "Go, sans-serif", "", "italic", nil
+	case "mono":
+		return "Go Mono, monospace", "", "", nil
+	case FontBasic:
+		return "monospace", "", "", nil
+	}
+	f, err := LoadFont(name)
+	if err != nil {
+		return "", "", "", err
+	}
+	family, err := f.Name(nil, sfnt.NameIDFamily)
+	if err != nil {
+		return "", "", "", fmt.Errorf("font %s has no family name: %w", name, err)
+	}
+	weight, style := "", ""
+	if sub, err := f.Name(nil, sfnt.NameIDSubfamily); err == nil {
+		sub = strings.ToLower(sub)
+		if strings.Contains(sub, "bold") {
+			weight = "bold"
+		}
+		if strings.Contains(sub, "italic") || strings.Contains(sub, "oblique") {
+			style = "italic"
+		}
+	}
+	return family, weight, style, nil
+}
+
+func (b borderPlan) writeSVG(sw *svgWriter) {
+	// The PNG border is drawn on the outermost pixels of rect, the line runs
+	// through their middle
+	x0, y0 := float64(b.rect.Min.X)+0.5, float64(b.rect.Min.Y)+0.5
+	x1, y1 := float64(b.rect.Max.X)-0.5, float64(b.rect.Max.Y)-0.5
+	box := fmt.Sprintf("<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" fill=\"none\" %s",
+		svgNumber(x0), svgNumber(y0), svgNumber(x1-x0), svgNumber(y1-y0), svgStroke(b.color, 1))
+	switch b.kind {
+	case BorderTypePlain:
+		sw.printf("%s/>\n", box)
+	case BorderTypeDotted:
+		sw.printf("%s stroke-dasharray=\"1 1\"/>\n", box)
+	case BorderTypeDashed:
+		sw.printf("%s stroke-dasharray=\"2 2\"/>\n", box)
+	case BorderTypeCorner:
+		// Same length as drawCornerBorder
+		n := 20.0
+		sw.printf("<path fill=\"none\" %s d=\"", svgStroke(b.color, 1))
+		for _, c := range [][4]float64{{x0, y0, -n, -n}, {x1, y0, n, -n}, {x0, y1, -n, n}, {x1, y1, n, n}} {
+			sw.printf("M%s %sh%sM%s %sv%s",
+				svgNumber(c[0]), svgNumber(c[1]), svgNumber(c[2]),
+				svgNumber(c[0]), svgNumber(c[1]), svgNumber(c[3]))
+		}
+		sw.printf("\"/>\n")
+	}
+}
+
+func (g guideLine) writeSVG(sw *svgWriter) {
+	// point returns the page coordinates of a point given along and across
+	// the guide
+	point := func(u, v float64) (string, string) {
+		if g.orientation == GuideVertical {
+			u, v = v, u
+		}
+		return svgNumber(u), svgNumber(v)
+	}
+	// A line of this width is centered on pos like in the PNG
+	center := float64(g.pos-g.width/2) + float64(g.width)/2
+
+	x1, y1 := point(float64(g.start), center)
+	x2, y2 := point(float64(g.end), center)
+	dash := ""
+	if g.kind == GuideTypeFold {
+		dash = fmt.Sprintf(" stroke-dasharray=\"%d %d\"", g.dash, g.gap)
+	}
+	sw.printf("<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" %s%s/>\n", x1, y1, x2, y2, svgStroke(g.color, float64(g.width)), dash)
+	if g.kind != GuideTypeCut {
+		return
+	}
+
+	// Scissors at the start of the line, like drawScissors
+	u, v, size := float64(g.start), float64(g.pos), float64(g.scissors)
+	r := float64(g.scissors / 6)
+	sw.printf("<g fill=\"none\" %s>\n", svgStroke(g.color, 1))
+	for _, cv := range []float64{v - float64(g.scissors/4), v + float64(g.scissors/4)} {
+		cx, cy := point(u+r, cv)
+		sw.printf("<circle cx=\"%s\" cy=\"%s\" r=\"%s\"/>\n", cx, cy, svgNumber(r))
+	}
+	for _, l := range [][4]float64{
+		{u + 2*r, v - float64(g.scissors/4), u + size, v + float64(g.scissors/5)},
+		{u + 2*r, v + float64(g.scissors/4), u + size, v - float64(g.scissors/5)},
+	} {
+		lx1, ly1 := point(l[0], l[1])
+		lx2, ly2 := point(l[2], l[3])
+		sw.printf("<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>\n", lx1, ly1, lx2, ly2)
+	}
+	sw.printf("</g>\n")
+}
+
+func svgRect(r image.Rectangle) string {
+	return fmt.Sprintf("<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/>", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+}
+
+func svgStroke(c color.RGBA, width float64) string {
+	s := fmt.Sprintf("stroke=\"rgb(%d,%d,%d)\" stroke-width=\"%s\"", c.R, c.G, c.B, svgNumber(width))
+	if c.A != 255 {
+		s += fmt.Sprintf(" stroke-opacity=\"%s\"", svgNumber(float64(c.A)/255))
+	}
+	return s
+}
+
+func svgFill(c color.RGBA) string {
+	s := fmt.Sprintf("fill=\"rgb(%d,%d,%d)\"", c.R, c.G, c.B)
+	if c.A != 255 {
+		s += fmt.Sprintf(" fill-opacity=\"%s\"", svgNumber(float64(c.A)/255))
+	}
+	return s
+}
+
+// svgNumber formats a coordinate with up to six decimals.
+func svgNumber(f float64) string {
+	f = math.Round(f*1e6) / 1e6
+	if f == 0 {
+		// no negative zero
+		f = 0
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func svgEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
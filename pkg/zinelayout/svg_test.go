@@ -0,0 +1,142 @@
+package zinelayout
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestItemTransform(t *testing.T) {
+	// every pixel has its own color, so that the raster result shows where
+	// each pixel went
+	input := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			input.SetRGBA(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	crop := image.Rect(1, 0, 4, 2)
+
+	for _, flip := range []Flip{FlipNone, FlipHorizontal, FlipVertical} {
+		for _, rotation := range []int{0, 90, 180, 270} {
+			rotated := rotateImage(flipImage(cropImage(input, crop), flip), rotation)
+			item := &itemPlan{
+				input:    input,
+				crop:     crop,
+				rotation: rotation,
+				flip:     flip,
+				place:    rotated.Bounds().Add(image.Pt(10, 20)),
+			}
+			m := item.transform()
+			for y := crop.Min.Y; y < crop.Max.Y; y++ {
+				for x := crop.Min.X; x < crop.Max.X; x++ {
+					px, py := float64(x)+0.5, float64(y)+0.5
+					gx := int(math.Floor(m.a*px + m.c*py + m.e))
+					gy := int(math.Floor(m.b*px + m.d*py + m.f))
+					got := rotated.At(gx-10, gy-20)
+					if got != color.Color(input.RGBAAt(x, y)) {
+						t.Errorf("flip %q rotation %d: pixel (%d, %d) maps to (%d, %d) which is %v", flip, rotation, x, y, gx, gy, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCreateOutputSVG(t *testing.T) {
+	spec := `
+global:
+  ppi: 100
+page_setup:
+  grid_size: {rows: 1, columns: 2}
+  bleed: {size: 5px, mirror: true}
+  crop_marks: {enabled: true}
+  crops:
+    2: {left: 10px}
+output_pages:
+  - id: front
+    border: {enabled: true, type: dashed}
+    guides:
+      - {type: cut, orientation: vertical, grid_line: 1}
+    text:
+      - {content: "Issue <7>", x: 0px, y: 0px}
+    layout:
+      - {input_index: 1, position: {row: 0, column: 0}, rotation: 90}
+      - {input_index: 2, position: {row: 0, column: 1}, flip: horizontal}
+`
+	var zl ZineLayout
+	if err := yaml.Unmarshal([]byte(spec), &zl); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inputs, err := GenerateTestImages(2, 100, 150)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = zl.CreateOutputSVG(&buf, zl.OutputPages[0], inputs, SVGOptions{InputHrefs: []string{"", "inputs/page 2.png"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	counts := map[string]int{}
+	var root xml.StartElement
+	decoder := xml.NewDecoder(&buf)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			if root.Name.Local == "" {
+				root = el
+			}
+			counts[el.Name.Local]++
+		}
+	}
+
+	attrs := map[string]string{}
+	for _, a := range root.Attr {
+		attrs[a.Name.Local] = a.Value
+	}
+	img, err := zl.CreateOutputImage(context.Background(), zl.OutputPages[0], inputs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	size := img.Bounds().Size()
+	if attrs["viewBox"] != "0 0 "+strconv.Itoa(size.X)+" "+strconv.Itoa(size.Y) {
+		t.Errorf("viewBox = %q, expected the %v pixel size of the PNG", attrs["viewBox"], size)
+	}
+	if !strings.HasSuffix(attrs["width"], "in") || !strings.HasSuffix(attrs["height"], "in") {
+		t.Errorf("size = %s x %s, expected inches", attrs["width"], attrs["height"])
+	}
+	if counts["image"] != 2 {
+		t.Errorf("got %d images, expected 2", counts["image"])
+	}
+	// eight reflections fill the mirrored bleed of each item, and each
+	// trim box is redrawn over the bleed of its neighbor
+	if counts["use"] != 2*8+2 {
+		t.Errorf("got %d uses, expected 18", counts["use"])
+	}
+	if counts["text"] != 1 {
+		t.Errorf("got %d texts, expected 1", counts["text"])
+	}
+	if counts["rect"] < 4 {
+		t.Errorf("got %d rects, expected clip paths, the background and borders", counts["rect"])
+	}
+	if counts["circle"] != 2 {
+		t.Errorf("got %d circles, expected the scissors of the cut guide", counts["circle"])
+	}
+}
@@ -0,0 +1,155 @@
+package zinelayout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// TextElement is a block of text drawn on an output page, such as a
+// colophon line or an issue number.
+//
+// It is placed either in a grid cell (Position) or in a box given by X and Y
+// from the top left corner of the sheet, with an optional Width and Height.
+// Without a size the box is as large as the text. The text is aligned
+// inside its box with Align.
+type TextElement struct {
+	Content  string      `yaml:"content"`
+	Font     string      `yaml:"font,omitempty"`
+	Size     string      `yaml:"size,omitempty"`
+	Color    CustomColor `yaml:"color,omitempty"`
+	Align    Alignment   `yaml:"align,omitempty"`
+	Position *Position   `yaml:"position,omitempty"`
+	X        string      `yaml:"x,omitempty"`
+	Y        string      `yaml:"y,omitempty"`
+	Width    string      `yaml:"width,omitempty"`
+	Height   string      `yaml:"height,omitempty"`
+}
+
+// box returns the rectangle the text is aligned in, given the cells of the
+// page and the size of the rendered text.
+func (t *TextElement) box(cellRect func(Position) (image.Rectangle, error), textSize image.Point, ppi float64) (image.Rectangle, error) {
+	if t.Position != nil {
+		if t.X != "" || t.Y != "" {
+			return image.Rectangle{}, fmt.Errorf("set either a position or x and y")
+		}
+		return cellRect(*t.Position)
+	}
+
+	values := []int{0, 0, textSize.X, textSize.Y}
+	for i, expr := range []string{t.X, t.Y, t.Width, t.Height} {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		px, err := ExpressionToPixels(expr, ppi)
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		values[i] = int(px)
+	}
+	return image.Rect(values[0], values[1], values[0]+values[2], values[1]+values[3]), nil
+}
+
+// placedText is a block of text laid out on an output page. The PNG
+// renderer draws the rendered block, the SVG renderer writes the lines as
+// text elements.
+type placedText struct {
+	lines []string
+	font  string
+	// size is the em size of the font in pixels.
+	size  float64
+	color color.RGBA
+	// align justifies the lines inside the block.
+	align Alignment
+	// rotation turns the block clockwise, for folios of rotated inputs.
+	rotation int
+	block    image.Image
+	rect     image.Rectangle
+}
+
+// layoutTextElements renders the text elements of a page and places them.
+func layoutTextElements(elements []*TextElement, cellRect func(Position) (image.Rectangle, error), ppi float64) ([]*placedText, error) {
+	var placed []*placedText
+	for i, t := range elements {
+		align := AlignCenter
+		if t.Align != "" {
+			a, err := ParseAlignment(string(t.Align))
+			if err != nil {
+				return nil, fmt.Errorf("text %d: %w", i+1, err)
+			}
+			align = a
+		}
+		sizeExpr := t.Size
+		if strings.TrimSpace(sizeExpr) == "" {
+			sizeExpr = "10pt"
+		}
+		size, err := ExpressionToPixels(sizeExpr, ppi)
+		if err != nil {
+			return nil, fmt.Errorf("text %d: size: %w", i+1, err)
+		}
+		if size < 1 {
+			return nil, fmt.Errorf("text %d: size must be at least one pixel, got %s", i+1, sizeExpr)
+		}
+		c := color.RGBA{0, 0, 0, 255}
+		if t.Color.RGBA != (color.RGBA{}) {
+			c = t.Color.RGBA
+		}
+
+		block, err := renderTextBlock(t.Content, t.Font, size, c, align)
+		if err != nil {
+			return nil, fmt.Errorf("text %d: %w", i+1, err)
+		}
+		box, err := t.box(cellRect, block.Bounds().Size(), ppi)
+		if err != nil {
+			return nil, fmt.Errorf("text %d: %w", i+1, err)
+		}
+		placed = append(placed, &placedText{
+			lines: strings.Split(strings.TrimRight(t.Content, "\n"), "\n"),
+			font:  t.Font,
+			size:  size,
+			color: c,
+			align: align,
+			block: block,
+			rect:  alignIn(box, block.Bounds().Size(), align),
+		})
+	}
+	return placed, nil
+}
+
+func (t *placedText) draw(dst *image.RGBA) {
+	draw.Draw(dst, t.rect, t.block, t.block.Bounds().Min, draw.Over)
+}
+
+// renderTextBlock renders one or more lines of text on a transparent image.
+// Lines are justified left, centered or right according to align.
+func renderTextBlock(content, fontName string, size float64, c color.Color, align Alignment) (image.Image, error) {
+	var lines []image.Image
+	width, height := 0, 0
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		img, err := renderLabel(line, fontName, size, c)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, img)
+		width = intMax(width, img.Bounds().Dx())
+		height += img.Bounds().Dy()
+	}
+
+	block := image.NewRGBA(image.Rect(0, 0, intMax(1, width), intMax(1, height)))
+	y := 0
+	for _, line := range lines {
+		b := line.Bounds()
+		x := (width - b.Dx()) / 2
+		if strings.HasSuffix(string(align), "left") {
+			x = 0
+		}
+		if strings.HasSuffix(string(align), "right") {
+			x = width - b.Dx()
+		}
+		draw.Draw(block, image.Rect(x, y, x+b.Dx(), y+b.Dy()), line, b.Min, draw.Over)
+		y += b.Dy()
+	}
+	return block, nil
+}
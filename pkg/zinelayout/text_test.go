@@ -0,0 +1,50 @@
+package zinelayout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTextElementBox(t *testing.T) {
+	cellRect := func(p Position) (image.Rectangle, error) {
+		return image.Rect(100*p.Column, 100*p.Row, 100*p.Column+100, 100*p.Row+100), nil
+	}
+	tests := []struct {
+		name     string
+		element  TextElement
+		expected image.Rectangle
+	}{
+		{"cell", TextElement{Position: &Position{Row: 1, Column: 2}}, image.Rect(200, 100, 300, 200)},
+		{"absolute", TextElement{X: "1in", Y: "0.5in", Width: "2in", Height: "10px"}, image.Rect(100, 50, 300, 60)},
+		{"text size", TextElement{X: "10px", Y: "20px"}, image.Rect(10, 20, 40, 32)},
+	}
+	for _, tt := range tests {
+		got, err := tt.element.box(cellRect, image.Pt(30, 12), 100)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: box = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+
+	bad := TextElement{Position: &Position{}, X: "1in"}
+	if _, err := bad.box(cellRect, image.Pt(1, 1), 100); err == nil {
+		t.Errorf("expected error for text with both position and x")
+	}
+}
+
+func TestRenderTextBlock(t *testing.T) {
+	one, err := renderTextBlock("Issue 7", "", 20, color.Black, AlignCenter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	two, err := renderTextBlock("Issue 7\nSpring", "", 20, color.Black, AlignLeft)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if two.Bounds().Dy() != 2*one.Bounds().Dy() {
+		t.Errorf("two lines are %d pixels high, expected %d", two.Bounds().Dy(), 2*one.Bounds().Dy())
+	}
+}
@@ -9,11 +9,31 @@ export interface Project {
   presetId?: string;
 }
 
+// Crop values are unit expressions, either edges or a rectangle.
+export interface Crop {
+  top?: string;
+  bottom?: string;
+  left?: string;
+  right?: string;
+  x?: string;
+  y?: string;
+  width?: string;
+  height?: string;
+}
+
 export interface ImageItem {
   id: string;
   name: string;
   width: number;
   height: number;
+  crop?: Crop;
+}
+
+// Split cuts each uploaded spread into single pages.
+export interface SplitOptions {
+  pages: number;
+  direction?: 'vertical' | 'horizontal';
+  gutter?: string;
 }
 
 export interface PresetInfo {
@@ -30,6 +50,18 @@ export interface ValidationDetails {
   columns: number;
   pages: number;
   multiple: number;
+  mixedSizes: boolean;
+}
+
+// RenderProgress is the state of the last render of a project. Done and
+// total count the steps of the current phase.
+export interface RenderProgress {
+  running: boolean;
+  phase?: 'load_spec' | 'decode_input' | 'compose_page' | 'encode_page' | 'encode_pdf';
+  name?: string;
+  done: number;
+  total: number;
+  elapsedMs: number;
 }
 
 export const api = createApi({
@@ -53,11 +85,19 @@ export const api = createApi({
       query: ({ id }) => `/projects/${id}/images`,
       providesTags: ['Image'],
     }),
-    uploadImages: b.mutation<{ images: ImageItem[] }, { id: string; files: FileList | File[] }>({
-      query: ({ id, files }) => {
+    uploadImages: b.mutation<
+      { images: ImageItem[] },
+      { id: string; files: FileList | File[]; split?: SplitOptions }
+    >({
+      query: ({ id, files, split }) => {
         const fd = new FormData();
         const list = Array.from(files as FileList);
         for (const f of list) fd.append('images[]', f);
+        if (split && split.pages > 1) {
+          fd.append('split', String(split.pages));
+          fd.append('split_direction', split.direction ?? 'vertical');
+          if (split.gutter) fd.append('split_gutter', split.gutter);
+        }
         return { url: `/projects/${id}/images`, method: 'POST', body: fd };
       },
       invalidatesTags: ['Image'],
@@ -69,6 +109,14 @@ export const api = createApi({
       }),
       invalidatesTags: ['Image'],
     }),
+    setImageCrop: b.mutation<{ crop: Crop | null }, { id: string; imageId: string; crop: Crop | null }>({
+      query: ({ id, imageId, crop }) => ({
+        url: `/projects/${id}/images/${encodeURIComponent(imageId)}/crop`,
+        method: crop ? 'PUT' : 'DELETE',
+        body: crop ?? undefined,
+      }),
+      invalidatesTags: ['Image'],
+    }),
     reorderImages: b.mutation<{ ok: boolean }, { id: string; order: string[] }>({
       query: ({ id, order }) => ({
         url: `/projects/${id}/images/reorder`,
@@ -150,12 +198,24 @@ export const api = createApi({
       query: ({ id }) => ({ url: `/projects/${id}/validate`, method: 'POST', body: {} }),
     }),
     renderProject: b.mutation<
-      { renderId: string; files: string[] },
-      { id: string; test?: boolean; test_bw?: boolean; test_dimensions?: string }
+      { renderId: string; files: string[]; pdf?: string; svgs?: string[] },
+      {
+        id: string;
+        test?: boolean;
+        test_bw?: boolean;
+        test_dimensions?: string;
+        formats?: string[];
+      }
     >({
       query: ({ id, ...body }) => ({ url: `/projects/${id}/render`, method: 'POST', body }),
     }),
-    getRenders: b.query<{ renders: { id: string; files: string[] }[] }, { id: string }>({
+    getRenderProgress: b.query<{ progress: RenderProgress }, { id: string }>({
+      query: ({ id }) => `/projects/${id}/render/progress`,
+    }),
+    getRenders: b.query<
+      { renders: { id: string; files: string[]; pdf?: string; svgs?: string[] }[] },
+      { id: string }
+    >({
       query: ({ id }) => `/projects/${id}/renders`,
     }),
   }),
@@ -169,6 +229,7 @@ export const {
   useUploadImagesMutation,
   useDeleteImageMutation,
   useReorderImagesMutation,
+  useSetImageCropMutation,
   useGetPresetsQuery,
   useGetPresetYamlQuery,
   useApplyPresetMutation,
@@ -177,5 +238,6 @@ export const {
   useValidateProjectQuery,
   useLazyValidateProjectQuery,
   useRenderProjectMutation,
+  useGetRenderProgressQuery,
   useGetRendersQuery,
 } = api;
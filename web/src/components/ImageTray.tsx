@@ -1,5 +1,6 @@
 import React, { useMemo, useRef, useState } from 'react';
 import {
+  type SplitOptions,
   useDeleteImageMutation,
   useGetImagesQuery,
   useReorderImagesMutation,
@@ -16,6 +17,7 @@ export const ImageTray: React.FC<{ id: string }> = ({ id }) => {
   const [order, setOrder] = useState<string[] | null>(null);
   const [dragIndex, setDragIndex] = useState<number | null>(null);
   const [isDragOverDropzone, setIsDragOverDropzone] = useState(false);
+  const [split, setSplit] = useState<SplitOptions>({ pages: 1, direction: 'vertical', gutter: '' });
 
   const currentOrder = order ?? data?.order ?? [];
   const imagesById = useMemo(() => {
@@ -38,7 +40,7 @@ export const ImageTray: React.FC<{ id: string }> = ({ id }) => {
     e.preventDefault();
     const files = fileRef.current?.files;
     if (!files || files.length === 0) return;
-    await uploadImages({ id, files }).unwrap();
+    await uploadImages({ id, files, split }).unwrap();
     if (fileRef.current) fileRef.current.value = '';
     setOrder(null);
     refetch();
@@ -97,7 +99,7 @@ export const ImageTray: React.FC<{ id: string }> = ({ id }) => {
       (f) => f.type === 'image/png' || f.name.toLowerCase().endsWith('.png'),
     );
     if (pngs.length === 0) return;
-    await uploadImages({ id, files: pngs }).unwrap();
+    await uploadImages({ id, files: pngs, split }).unwrap();
     setOrder(null);
     refetch();
   };
@@ -109,6 +111,37 @@ export const ImageTray: React.FC<{ id: string }> = ({ id }) => {
         style={{ display: 'flex', gap: 8, alignItems: 'center', marginBottom: 12 }}
       >
         <input ref={fileRef} type="file" accept="image/png" multiple />
+        <label>
+          Split into{' '}
+          <select
+            value={split.pages}
+            onChange={(e) => setSplit({ ...split, pages: Number(e.target.value) })}
+          >
+            <option value={1}>1 page</option>
+            <option value={2}>2 pages</option>
+            <option value={3}>3 pages</option>
+            <option value={4}>4 pages</option>
+          </select>
+        </label>
+        {split.pages > 1 && (
+          <>
+            <select
+              value={split.direction}
+              onChange={(e) =>
+                setSplit({ ...split, direction: e.target.value as SplitOptions['direction'] })
+              }
+            >
+              <option value="vertical">side by side</option>
+              <option value="horizontal">top to bottom</option>
+            </select>
+            <input
+              type="text"
+              placeholder="gutter, e.g. 0.25in"
+              value={split.gutter}
+              onChange={(e) => setSplit({ ...split, gutter: e.target.value })}
+            />
+          </>
+        )}
         <button type="submit" disabled={isUploading}>
           Upload
         </button>
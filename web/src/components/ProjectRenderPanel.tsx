@@ -1,15 +1,37 @@
 import React from 'react';
-import { useGetRendersQuery, useRenderProjectMutation } from '../api';
+import { useGetRenderProgressQuery, useGetRendersQuery, useRenderProjectMutation } from '../api';
+
+const phaseLabels: Record<string, string> = {
+  load_spec: 'Loading spec',
+  decode_input: 'Decoding inputs',
+  compose_page: 'Composing pages',
+  encode_page: 'Encoding pages',
+  encode_pdf: 'Encoding PDF',
+};
 
 export const ProjectRenderPanel: React.FC<{ id: string }> = ({ id }) => {
   const { data, refetch, isFetching } = useGetRendersQuery({ id });
   const [renderProject, { isLoading }] = useRenderProjectMutation();
+  // poll the progress of the running render
+  const { data: progressData } = useGetRenderProgressQuery(
+    { id },
+    { pollingInterval: 500, skip: !isLoading },
+  );
+  const progress = isLoading && progressData?.progress.running ? progressData.progress : undefined;
   const [test, setTest] = React.useState(false);
   const [testBW, setTestBW] = React.useState(false);
   const [testDimensions, setTestDimensions] = React.useState('600px,800px');
+  const [pdf, setPdf] = React.useState(false);
+  const [svg, setSvg] = React.useState(false);
 
   const onRender = async () => {
-    await renderProject({ id, test, test_bw: testBW, test_dimensions: testDimensions }).unwrap();
+    await renderProject({
+      id,
+      test,
+      test_bw: testBW,
+      test_dimensions: testDimensions,
+      formats: ['png', ...(pdf ? ['pdf'] : []), ...(svg ? ['svg'] : [])],
+    }).unwrap();
     refetch();
   };
 
@@ -30,6 +52,12 @@ export const ProjectRenderPanel: React.FC<{ id: string }> = ({ id }) => {
           placeholder="WIDTH,HEIGHT"
           style={{ width: 160 }}
         />
+        <label>
+          <input type="checkbox" checked={pdf} onChange={(e) => setPdf(e.target.checked)} /> PDF
+        </label>
+        <label>
+          <input type="checkbox" checked={svg} onChange={(e) => setSvg(e.target.checked)} /> SVG
+        </label>
         <button type="button" disabled={isLoading} onClick={onRender}>
           Render
         </button>
@@ -37,6 +65,15 @@ export const ProjectRenderPanel: React.FC<{ id: string }> = ({ id }) => {
           Refresh
         </button>
       </div>
+      {progress ? (
+        <div style={{ display: 'flex', gap: 8, alignItems: 'center', marginTop: 8 }}>
+          <span>{progress.phase ? phaseLabels[progress.phase] ?? progress.phase : 'Starting'}</span>
+          <progress value={progress.done} max={progress.total || 1} style={{ width: 240 }} />
+          <span>
+            {progress.done}/{progress.total} {progress.name} ({(progress.elapsedMs / 1000).toFixed(1)}s)
+          </span>
+        </div>
+      ) : null}
       <div style={{ marginTop: 8 }}>
         {data?.renders?.length ? (
           <div style={{ display: 'flex', flexDirection: 'column', gap: 12 }}>
@@ -45,6 +82,18 @@ export const ProjectRenderPanel: React.FC<{ id: string }> = ({ id }) => {
                 <div style={{ display: 'flex', alignItems: 'center', gap: 8 }}>
                   <strong>{r.id}</strong>
                   <a href={`/api/projects/${id}/renders/${r.id}/download.zip`}>Download ZIP</a>
+                  {r.pdf ? (
+                    <a href={`/api/projects/${id}/renders/${r.id}/download.pdf`}>Download PDF</a>
+                  ) : null}
+                  {r.svgs?.map((f) => (
+                    <a
+                      key={f}
+                      href={`/api/projects/${id}/renders/${r.id}/files/${encodeURIComponent(f)}`}
+                      download
+                    >
+                      {f}
+                    </a>
+                  ))}
                 </div>
                 <div style={{ display: 'flex', gap: 8, marginTop: 8, overflowX: 'auto' }}>
                   {r.files.map((f) => (